@@ -0,0 +1,385 @@
+package paths
+
+import (
+	"math"
+	"time"
+)
+
+// A Vector is a position in the world.
+type Vector struct {
+	X, Y float64
+}
+
+// An AnyAngleResult is the outcome of an any-angle search (see Grid.ThetaStar()), along with some statistics about it.
+type AnyAngleResult struct {
+	// Waypoints are the world positions of the centers of the Cells the route turns at, from the start to the destination, or
+	// nil if Err is set. Moving in a straight line from each waypoint to the next follows the route.
+	Waypoints []Vector
+	// Path holds every Cell the route passes through, in order, or is nil if Err is set.
+	Path *Path
+	SearchStats
+}
+
+// ThetaStar finds an any-angle route from the starting Cell to the destination Cell using Theta*. Rather than moving from one
+// Cell to a neighboring one, the route heads in a straight line between any two Cells that can see each other, so it only
+// turns at the corners of obstacles, rather than zig-zagging along the Grid's axes.
+//
+// Moving in a straight line costs its length (in Cells) multiplied by the highest cost of the steps it takes between the Cells
+// it passes through, each costed as a step onto its Cell would be, taking edge costs, the Grid's EdgeCostFunc, and the options'
+// CostFunc into account. A line is blocked by any Cell that isn't walkable, or any step between the Cells along it that can't
+// be taken (see SetEdgeCost() and PathOptions.PassableFunc); where it passes exactly through the corner between Cells,
+// CornerCutting decides whether the Cells on either side block it. Otherwise, options controls the search as it does for
+// Grid.SearchFromCells(), other than Movement, DiagonalCost, and Algorithm, which don't apply. If options has no Heuristic,
+// Euclidean is used.
+//
+// The routes Theta* finds aren't always the very shortest possible, but they're close, and they look far more natural.
+func (m *Grid) ThetaStar(start, dest *Cell, options *PathOptions) *AnyAngleResult {
+	return m.anyAngle(start, dest, options, false)
+}
+
+// LazyThetaStar finds an any-angle route from the starting Cell to the destination Cell using Lazy Theta*, as with ThetaStar().
+// Lazy Theta* waits to check whether Cells can see each other until it has to, which makes it quite a bit faster, particularly
+// on open Grids, though the route found can be slightly longer.
+func (m *Grid) LazyThetaStar(start, dest *Cell, options *PathOptions) *AnyAngleResult {
+	return m.anyAngle(start, dest, options, true)
+}
+
+func (m *Grid) anyAngle(start, dest *Cell, options *PathOptions, lazy bool) *AnyAngleResult {
+
+	began := time.Now()
+
+	if err := checkEnds(start, dest); err != nil && (err != ErrDestBlocked || !options.allowPartial()) {
+		return &AnyAngleResult{SearchStats: SearchStats{Err: err}}
+	}
+
+	// The search state is kept in a Pathfinder's indexSearch, so that the memory can be reused between searches.
+	pf, ok := m.pathfinders.Get().(*Pathfinder)
+	if !ok {
+		pf = NewPathfinder(m)
+	}
+	defer m.pathfinders.Put(pf)
+
+	s := &thetaSearch{
+		grid:  m,
+		dest:  dest,
+		lazy:  lazy,
+		state: &pf.search,
+	}
+
+	// Lines can head off in any direction, so neighbors are always checked diagonally, too.
+	if options != nil {
+		s.options = *options
+	}
+	s.options.Movement = MoveDiagonal
+
+	s.heuristic = s.options.heuristic()
+	if s.heuristic == nil {
+		s.heuristic = Euclidean
+	}
+	s.weight = s.options.heuristicWeight()
+	s.state.reset(m.Nodes(), s.options.tieBreak())
+
+	result := s.run(start)
+	result.Duration = time.Since(began)
+	return result
+
+}
+
+// thetaSearch is an any-angle search. Each Cell it reaches (by index) has a parent, which it's reached from in a straight line;
+// the start is its own parent. The Cells' costs and parents are stored as the records of an indexSearch, whose open heap holds
+// the Cells to check.
+type thetaSearch struct {
+	grid      *Grid
+	dest      *Cell
+	options   PathOptions
+	lazy      bool
+	heuristic Heuristic
+	weight    float64
+
+	state     *indexSearch
+	neighbors []neighbor
+	checked   int
+	limited   bool
+}
+
+func (s *thetaSearch) run(start *Cell) *AnyAngleResult {
+
+	si := s.index(start)
+	s.push(start, si, start.Cost)
+
+	end := -1
+	partial := false
+	closestScore := 0.0
+
+	for s.state.open.Len() > 0 {
+
+		i := int(s.state.records[s.state.open.pop().slot].node)
+		if s.closed(i) {
+			continue
+		}
+		cell := s.cell(i)
+
+		if s.lazy {
+			s.checkParent(cell)
+		}
+
+		s.record(i).closed = true
+		s.checked++
+
+		if cell == s.dest {
+			end, partial = i, false
+			break
+		}
+
+		if s.options.allowPartial() {
+			score := s.options.partialScore(cell, s.dest)
+			if end < 0 || score < closestScore || (score == closestScore && s.cost(i) < s.cost(end)) {
+				end, partial, closestScore = i, true, score
+			}
+		}
+
+		if maxNodes := s.options.maxNodes(); maxNodes > 0 && s.checked >= maxNodes {
+			s.limited = true
+			break
+		}
+
+		s.neighbors = s.grid.appendNeighbors(s.neighbors[:0], cell, &s.options)
+		for _, n := range s.neighbors {
+			if !s.closed(s.index(n.Cell)) {
+				s.update(i, n.Cell)
+			}
+		}
+
+	}
+
+	result := &AnyAngleResult{SearchStats: SearchStats{NodesExpanded: s.checked}}
+
+	if end < 0 {
+		if s.limited {
+			result.Err = ErrSearchLimit
+		} else {
+			result.Err = ErrNoPath
+		}
+		return result
+	}
+
+	result.Cost = s.cost(end)
+	result.Partial = partial
+
+	// Follow the parents back to the start to find the corners, then fill in the Cells along each line between them.
+	corners := []*Cell{}
+	for i := end; ; i = s.parent(i) {
+		corners = append(corners, s.cell(i))
+		if s.parent(i) == i {
+			break
+		}
+	}
+
+	result.Path = &Path{Cells: []*Cell{corners[len(corners)-1]}}
+	result.Waypoints = make([]Vector, 0, len(corners))
+
+	for i := len(corners) - 1; i >= 0; i-- {
+		result.Waypoints = append(result.Waypoints, s.grid.cellCenter(corners[i]))
+		if i < len(corners)-1 {
+			s.grid.traceLine(corners[i+1], corners[i], func(prev, next *Cell, diagonal bool) bool {
+				result.Path.Cells = append(result.Path.Cells, next)
+				return true
+			})
+		}
+	}
+
+	return result
+
+}
+
+func (s *thetaSearch) index(cell *Cell) int {
+	return cell.Y*s.grid.Width() + cell.X
+}
+
+func (s *thetaSearch) cell(index int) *Cell {
+	w := s.grid.Width()
+	return s.grid.Get(index%w, index/w)
+}
+
+// record returns the search state of the Cell at the index provided, or nil if the search hasn't reached it.
+func (s *thetaSearch) record(i int) *indexRecord {
+	if slot, ok := s.state.slot(i); ok {
+		return &s.state.records[slot]
+	}
+	return nil
+}
+
+// cost returns the cost of reaching the Cell at the index provided, or positive infinity if the search hasn't reached it.
+func (s *thetaSearch) cost(i int) float64 {
+	if r := s.record(i); r != nil {
+		return r.cost
+	}
+	return math.Inf(1)
+}
+
+// closed returns whether the Cell at the index provided has been checked.
+func (s *thetaSearch) closed(i int) bool {
+	r := s.record(i)
+	return r != nil && r.closed
+}
+
+// parent returns the index of the Cell that the Cell at the index provided is reached from.
+func (s *thetaSearch) parent(i int) int {
+	return int(s.state.records[s.record(i).parent].node)
+}
+
+// setParent sets the Cell that the Cell at the index provided is reached from, and the cost of reaching it that way. The
+// parent has to have been reached already, unless it's the Cell itself.
+func (s *thetaSearch) setParent(i, parent int, cost float64) {
+	r := s.record(i)
+	r.cost = cost
+	r.parent, _ = s.state.slot(parent)
+}
+
+// push records a cheaper way to reach the Cell provided from the parent given, and adds it to the Cells to check.
+func (s *thetaSearch) push(cell *Cell, parent int, cost float64) {
+
+	i := s.index(cell)
+	if s.record(i) == nil {
+		s.state.slots[i] = int32(len(s.state.records))
+		s.state.records = append(s.state.records, indexRecord{node: int32(i)})
+	}
+	s.setParent(i, parent, cost)
+
+	estimate := s.heuristic.Estimate(cell, s.dest) * s.weight
+	s.state.open.push(indexItem{s.state.slots[i], cost, estimate})
+
+}
+
+// update tries reaching the neighbor provided straight from the parent of the Cell at index i, as well as by stepping from the
+// Cell itself, since a line from the parent can cross costlier Cells than the step does. Lazy Theta* assumes the parent can
+// see the neighbor, and only checks once the neighbor is expanded.
+func (s *thetaSearch) update(i int, neighbor *Cell) {
+
+	p := s.parent(i)
+	parent, cost := p, math.Inf(1)
+
+	if s.lazy {
+		cost = s.cost(p) + s.grid.lineLength(s.cell(p), neighbor)*neighbor.Cost
+	} else {
+		cost = s.cost(p) + s.grid.lineCost(s.cell(p), neighbor, &s.options)
+		if c := s.cost(i) + s.grid.lineCost(s.cell(i), neighbor, &s.options); c < cost {
+			parent, cost = i, c
+		}
+	}
+
+	if maxCost := s.options.maxCost(); maxCost > 0 && cost > maxCost {
+		s.limited = true
+		return
+	}
+
+	if cost < s.cost(s.index(neighbor)) {
+		s.push(neighbor, parent, cost)
+	}
+
+}
+
+// checkParent checks that a Cell reached by Lazy Theta* can actually see its parent, as assumed, and what the line from it
+// really costs. The Cell is then reached from whichever is cheapest of its parent and its already-expanded neighbors (there's
+// always one that can reach it, as it was reached from one).
+func (s *thetaSearch) checkParent(cell *Cell) {
+
+	i := s.index(cell)
+	p := s.parent(i)
+	if p == i {
+		return
+	}
+
+	best, bestCost := p, s.cost(p)+s.grid.lineCost(s.cell(p), cell, &s.options)
+	s.neighbors = s.grid.appendPredecessors(s.neighbors[:0], cell, &s.options)
+	for _, n := range s.neighbors {
+		ni := s.index(n.Cell)
+		if !s.closed(ni) {
+			continue
+		}
+		if cost := s.cost(ni) + s.grid.lineCost(n.Cell, cell, &s.options); cost < bestCost {
+			best, bestCost = ni, cost
+		}
+	}
+	s.setParent(i, best, bestCost)
+
+}
+
+// cellCenter returns the world position of the center of the Cell provided.
+func (m *Grid) cellCenter(cell *Cell) Vector {
+	x, y := m.GridToWorld(cell.X, cell.Y)
+	return Vector{x + float64(m.CellWidth)/2, y + float64(m.CellHeight)/2}
+}
+
+// lineLength returns the length (in Cells) of the straight line between the centers of two Cells.
+func (m *Grid) lineLength(from, to *Cell) float64 {
+	return math.Hypot(float64(to.X-from.X), float64(to.Y-from.Y))
+}
+
+// lineCost returns the cost of moving in a straight line from the center of one Cell to the center of another: its length
+// multiplied by the highest cost of the steps it takes between the Cells it passes through. If the line is blocked, lineCost
+// returns positive infinity.
+func (m *Grid) lineCost(from, to *Cell, options *PathOptions) float64 {
+
+	highest := 0.0
+
+	clear := m.traceLine(from, to, func(prev, next *Cell, diagonal bool) bool {
+		if !next.Walkable || (diagonal && m.cornerBlocked(prev, m.Get(next.X, prev.Y), m.Get(prev.X, next.Y), options)) {
+			return false
+		}
+		cost := m.adjustStep(prev, next, next.Cost, options)
+		if math.IsInf(cost, 1) {
+			return false
+		}
+		highest = math.Max(highest, cost)
+		return true
+	})
+
+	if !clear {
+		return math.Inf(1)
+	}
+	return m.lineLength(from, to) * highest
+
+}
+
+// traceLine walks the Cells that a straight line from the center of one Cell to the center of another passes through, calling
+// visit with each Cell entered and the one before it. Where the line passes exactly through the corner between Cells, it steps
+// diagonally, and diagonal is true. If visit returns false, the walk stops there, and traceLine returns false as well.
+func (m *Grid) traceLine(from, to *Cell, visit func(prev, next *Cell, diagonal bool) bool) bool {
+
+	dx, dy := to.X-from.X, to.Y-from.Y
+	nx, ny := abs(dx), abs(dy)
+	sx, sy := sign(dx), sign(dy)
+
+	x, y := from.X, from.Y
+	prev := from
+
+	for ix, iy := 0, 0; ix < nx || iy < ny; {
+
+		// Compare how far along the line the next vertical and horizontal Cell borders are crossed, and step across whichever
+		// comes first (or both at once).
+		diagonal := false
+		switch d := (1+2*ix)*ny - (1+2*iy)*nx; {
+		case d == 0:
+			x, y = x+sx, y+sy
+			ix, iy = ix+1, iy+1
+			diagonal = true
+		case d < 0:
+			x += sx
+			ix++
+		default:
+			y += sy
+			iy++
+		}
+
+		next := m.Get(x, y)
+		if !visit(prev, next, diagonal) {
+			return false
+		}
+		prev = next
+
+	}
+
+	return true
+
+}
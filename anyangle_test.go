@@ -0,0 +1,110 @@
+package paths
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func TestThetaStarMatchesSearch(t *testing.T) {
+
+	rng := rand.New(rand.NewSource(12))
+
+	// With diagonal steps costing the square root of 2, as they would in a straight line, Theta* routes across Cells that all
+	// cost 1 can only be as costly as the cheapest Path along the Grid, and no cheaper than a straight line to the destination.
+	grid := &PathOptions{Movement: MoveDiagonal, DiagonalCost: math.Sqrt2 - 1}
+
+	for i := 0; i < 20; i++ {
+
+		costs := i%4 == 3
+		m := randomGrid(rng, 30, 30, 0.25, costs)
+
+		for j := 0; j < 30; j++ {
+
+			start, dest := randomCell(rng, m), randomCell(rng, m)
+			want := m.SearchFromCells(start, dest, grid)
+
+			for _, lazy := range []bool{false, true} {
+
+				got := m.anyAngle(start, dest, nil, lazy)
+				if got.Err != want.Err {
+					t.Fatalf("any-angle search (lazy: %t) from %s to %s found %v, but searching found %v", lazy, start, dest, got.Err, want.Err)
+				}
+				if got.Err != nil {
+					continue
+				}
+
+				if _, bad := pathCost(m, got.Path, grid); bad != "" || got.Path.Cells[0] != start || got.Path.Cells[len(got.Path.Cells)-1] != dest {
+					t.Fatalf("any-angle search (lazy: %t) from %s to %s returned a Path that isn't valid (%s)", lazy, start, dest, bad)
+				}
+				if len(got.Waypoints) < 2 || got.Waypoints[0] != m.cellCenter(start) || got.Waypoints[len(got.Waypoints)-1] != m.cellCenter(dest) {
+					t.Fatalf("any-angle search (lazy: %t) from %s to %s returned waypoints %v", lazy, start, dest, got.Waypoints)
+				}
+
+				// The cost reported has to be that of the lines between the waypoints.
+				cost := start.Cost
+				for k := 1; k < len(got.Waypoints); k++ {
+					from := m.Get(m.WorldToGrid(got.Waypoints[k-1].X, got.Waypoints[k-1].Y))
+					to := m.Get(m.WorldToGrid(got.Waypoints[k].X, got.Waypoints[k].Y))
+					cost += m.lineCost(from, to, nil)
+				}
+				if math.Abs(cost-got.Cost) > 1e-9 {
+					t.Fatalf("any-angle search (lazy: %t) from %s to %s reported a cost of %f, but its waypoints cost %f", lazy, start, dest, got.Cost, cost)
+				}
+
+				// Lazy Theta* can find slightly costlier routes than Theta*, and lines across Cells of varying Costs can cost more
+				// than moving around them.
+				if straight := start.Cost + m.lineLength(start, dest); got.Cost < straight-1e-9 || (!lazy && !costs && got.Cost > want.Cost+1e-9) {
+					t.Fatalf("any-angle search (lazy: %t) from %s to %s costs %f, outside of %f to %f", lazy, start, dest, got.Cost, straight, want.Cost)
+				}
+
+			}
+
+		}
+
+	}
+
+}
+
+func TestThetaStarCostFunc(t *testing.T) {
+
+	// The swamp down the middle is only costly for tanks, through their CostFunc.
+	m := NewGridFromStringArrays([]string{
+		"         ",
+		"    s    ",
+		"    s    ",
+		"    s    ",
+		"    s    ",
+		"    s    ",
+		"         ",
+	}, 16, 16)
+	tank := &PathOptions{
+		Agent: "tank",
+		CostFunc: func(from, to *Cell, cost float64, agent interface{}) float64 {
+			if agent == "tank" && to.Rune == 's' {
+				return cost * 20
+			}
+			return cost
+		},
+	}
+	start, dest := m.Get(0, 3), m.Get(8, 3)
+
+	for _, lazy := range []bool{false, true} {
+
+		if route := m.anyAngle(start, dest, nil, lazy); len(route.Waypoints) != 2 {
+			t.Fatalf("any-angle search (lazy: %t) without a CostFunc should head straight across, not through %v", lazy, route.Waypoints)
+		}
+
+		route := m.anyAngle(start, dest, tank, lazy)
+		if route.Err != nil {
+			t.Fatalf("any-angle search (lazy: %t) for a tank failed: %v", lazy, route.Err)
+		}
+		for _, cell := range route.Path.Cells {
+			if cell.Rune == 's' {
+				t.Fatalf("any-angle search (lazy: %t) for a tank goes through the swamp at %s", lazy, cell)
+			}
+		}
+
+	}
+
+}
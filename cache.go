@@ -0,0 +1,157 @@
+package paths
+
+import (
+	"container/list"
+	"sync"
+)
+
+// A PathCache remembers the results of recent searches on a Grid, so that asking for the same route again (with the same start
+// Cell, destination Cell, and *PathOptions pointer) doesn't search the Grid again. When the cache is full, the least recently
+// used result is forgotten.
+//
+// A cached Path is thrown out as soon as any Cell along it changes its walkability or Cost. Searches that didn't reach their
+// destination (i.e. ErrNoPath, or partial Paths) are thrown out when any Cell in the Grid changes, as the change could have
+// opened a way through. Changes are only noticed if they're made through the Grid's setters (SetCellWalkable(), SetCellCost(),
+// and so on), or reported with Grid.MarkChanged().
+//
+// A PathCache can be used from multiple goroutines at once. Like a PathService, it holds the Grid's read lock while checking
+// and searching it, so other goroutines should change the Grid between Grid.Lock() and Grid.Unlock(), and callers shouldn't
+// hold the read lock themselves.
+type PathCache struct {
+	Grid *Grid
+
+	mutex    sync.Mutex
+	capacity int
+	entries  map[pathKey]*list.Element
+	order    *list.List
+}
+
+// cacheEntry is a cached search result, along with the Grid's version when it was last known to be valid.
+type cacheEntry struct {
+	key     pathKey
+	result  *PathResult
+	version uint64
+}
+
+// NewPathCache returns a new PathCache for the Grid provided, which remembers up to capacity results.
+func NewPathCache(grid *Grid, capacity int) *PathCache {
+	return &PathCache{
+		Grid:     grid,
+		capacity: capacity,
+		entries:  map[pathKey]*list.Element{},
+		order:    list.New(),
+	}
+}
+
+// GetPathFromCells returns a Path from the starting Cell to the destination Cell, as with Grid.GetPathFromCells(), using a
+// cached result if there's a valid one.
+func (c *PathCache) GetPathFromCells(start, dest *Cell, options *PathOptions) *Path {
+	return pathFromResult(c.SearchFromCells(start, dest, options))
+}
+
+// GetPath returns a Path from the world positions provided, as with Grid.GetPath(), using a cached result if there's a valid
+// one.
+func (c *PathCache) GetPath(startX, startY, endX, endY float64, options *PathOptions) *Path {
+	sx, sy := c.Grid.WorldToGrid(startX, startY)
+	ex, ey := c.Grid.WorldToGrid(endX, endY)
+	return c.GetPathFromCells(c.Grid.Get(sx, sy), c.Grid.Get(ex, ey), options)
+}
+
+// SearchFromCells searches for a Path from the starting Cell to the destination Cell, as with Grid.SearchFromCells(), using a
+// cached result if there's a valid one. Each call returns its own copy of the result, so the Paths returned can be followed
+// separately.
+func (c *PathCache) SearchFromCells(start, dest *Cell, options *PathOptions) *PathResult {
+
+	key := pathKey{start, dest, options}
+
+	c.Grid.RLock()
+	defer c.Grid.RUnlock()
+
+	c.mutex.Lock()
+	if element, ok := c.entries[key]; ok {
+		entry := element.Value.(*cacheEntry)
+		if c.valid(entry) {
+			c.order.MoveToFront(element)
+			result := entry.result.copy()
+			c.mutex.Unlock()
+			return result
+		}
+		c.remove(element)
+	}
+	version := c.Grid.Version()
+	c.mutex.Unlock()
+
+	result := c.Grid.SearchFromCells(start, dest, options)
+
+	// Errors like ErrStartBlocked are found without searching, so there's no point in remembering them.
+	if result.Err != nil && result.Err != ErrNoPath && result.Err != ErrSearchLimit {
+		return result
+	}
+
+	c.mutex.Lock()
+	if element, ok := c.entries[key]; ok {
+		c.remove(element)
+	}
+	if c.capacity > 0 {
+		c.entries[key] = c.order.PushFront(&cacheEntry{key: key, result: result.copy(), version: version})
+		for c.order.Len() > c.capacity {
+			c.remove(c.order.Back())
+		}
+	}
+	c.mutex.Unlock()
+
+	return result
+
+}
+
+// Len returns the number of results in the PathCache.
+func (c *PathCache) Len() int {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.order.Len()
+}
+
+// Clear forgets all cached results.
+func (c *PathCache) Clear() {
+	c.mutex.Lock()
+	c.entries = map[pathKey]*list.Element{}
+	c.order.Init()
+	c.mutex.Unlock()
+}
+
+// valid returns whether the cached entry is still valid, updating its version if so.
+func (c *PathCache) valid(entry *cacheEntry) bool {
+
+	version := c.Grid.Version()
+	if entry.version == version {
+		return true
+	}
+
+	if entry.result.Err != nil || entry.result.Partial {
+		return false
+	}
+
+	cells := entry.result.Path.Cells
+	for i, cell := range cells {
+		if c.Grid.cellVersion(cell) > entry.version {
+			return false
+		}
+		// Diagonal steps also depend on the Cells at the corners they pass.
+		if i > 0 && cell.X != cells[i-1].X && cell.Y != cells[i-1].Y {
+			for _, corner := range [2]*Cell{c.Grid.Get(cell.X, cells[i-1].Y), c.Grid.Get(cells[i-1].X, cell.Y)} {
+				if corner != nil && c.Grid.cellVersion(corner) > entry.version {
+					return false
+				}
+			}
+		}
+	}
+
+	entry.version = version
+	return true
+
+}
+
+func (c *PathCache) remove(element *list.Element) {
+	delete(c.entries, element.Value.(*cacheEntry).key)
+	c.order.Remove(element)
+}
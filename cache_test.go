@@ -0,0 +1,191 @@
+package paths
+
+import (
+	"math/rand"
+	"sync"
+	"testing"
+)
+
+// countingOptions returns PathOptions that count the steps checked by searches using them, so that tests can tell whether a
+// PathCache searched the Grid or used a cached result.
+func countingOptions(steps *int) *PathOptions {
+	return &PathOptions{
+		Movement: MoveDiagonal,
+		CostFunc: func(from, to *Cell, cost float64, agent interface{}) float64 {
+			*steps++
+			return cost
+		},
+	}
+}
+
+func TestPathCacheEviction(t *testing.T) {
+
+	m := NewGrid(10, 10, 16, 16)
+	steps := 0
+	options := countingOptions(&steps)
+	cache := NewPathCache(m, 2)
+
+	searched := func(start, dest *Cell) bool {
+		before := steps
+		if result := cache.SearchFromCells(start, dest, options); result.Err != nil {
+			t.Fatalf("PathCache failed to find a Path from %s to %s: %v", start, dest, result.Err)
+		}
+		return steps != before
+	}
+
+	a, b, c := m.Get(0, 0), m.Get(9, 9), m.Get(0, 9)
+
+	if !searched(a, b) || !searched(b, a) {
+		t.Fatalf("PathCache didn't search for new routes")
+	}
+	if searched(a, b) {
+		t.Fatalf("PathCache searched again for a cached route")
+	}
+
+	// The route from b to a is now the least recently used, so it's the one forgotten to make room.
+	if !searched(a, c) {
+		t.Fatalf("PathCache didn't search for a new route")
+	}
+	if cache.Len() != 2 {
+		t.Fatalf("PathCache with a capacity of 2 holds %d results", cache.Len())
+	}
+	if searched(a, b) {
+		t.Fatalf("PathCache forgot the most recently used route rather than the least")
+	}
+	if !searched(b, a) {
+		t.Fatalf("PathCache didn't forget the least recently used route")
+	}
+
+	// Each call gets its own copy of the cached Path.
+	if cache.GetPathFromCells(a, b, options) == cache.GetPathFromCells(a, b, options) {
+		t.Fatalf("PathCache returned the same Path twice")
+	}
+
+	cache.Clear()
+	if cache.Len() != 0 || !searched(a, b) {
+		t.Fatalf("PathCache still holds results after being cleared")
+	}
+
+}
+
+func TestPathCacheInvalidation(t *testing.T) {
+
+	m := NewGrid(10, 10, 16, 16)
+	steps := 0
+	options := countingOptions(&steps)
+	cache := NewPathCache(m, 10)
+	start, dest := m.Get(0, 0), m.Get(9, 9)
+
+	searched := func() bool {
+		before := steps
+		cache.SearchFromCells(start, dest, options)
+		return steps != before
+	}
+
+	searched()
+	path := cache.GetPathFromCells(start, dest, options)
+
+	// Changing a Cell off of the Path, or setting a Cell to what it already is, leaves the cached Path valid.
+	m.SetCellCost(m.Get(9, 0), 5)
+	m.SetCellWalkable(path.Cells[3], true)
+	if searched() {
+		t.Fatalf("PathCache searched again after a Cell off of the cached Path changed")
+	}
+
+	m.SetCellCost(path.Cells[3], 5)
+	if !searched() {
+		t.Fatalf("PathCache didn't search again after a Cell on the cached Path changed")
+	}
+
+	// Changing Cells directly goes unnoticed, until it's reported with MarkChanged().
+	path = cache.GetPathFromCells(start, dest, options)
+	path.Cells[4].Walkable = false
+	if searched() {
+		t.Fatalf("PathCache noticed a Cell changed without being told")
+	}
+	m.MarkChanged(path.Cells[4])
+	if !searched() {
+		t.Fatalf("PathCache didn't search again after a change to a Cell on the cached Path was reported")
+	}
+
+	// A diagonal step depends on the Cells at its corners, too.
+	m = NewGrid(2, 2, 16, 16)
+	cache = NewPathCache(m, 10)
+	start, dest = m.Get(0, 0), m.Get(1, 1)
+	searched()
+	m.SetCellCost(m.Get(1, 0), 2)
+	if !searched() {
+		t.Fatalf("PathCache didn't search again after a Cell at the corner of a diagonal step changed")
+	}
+
+	// A search that didn't find a way through could be wrong after any change.
+	m = NewGridFromStringArrays([]string{
+		"  x  ",
+		"  x  ",
+		"  x  ",
+	}, 16, 16)
+	m.SetWalkable('x', false)
+	cache = NewPathCache(m, 10)
+	start, dest = m.Get(0, 0), m.Get(4, 0)
+	if cache.SearchFromCells(start, dest, options).Err != ErrNoPath {
+		t.Fatalf("PathCache found a Path through a wall")
+	}
+	m.SetCellWalkable(m.Get(2, 2), true)
+	if result := cache.SearchFromCells(start, dest, options); result.Err != nil {
+		t.Fatalf("PathCache didn't search again for a route that had no Path after the wall was opened: %v", result.Err)
+	}
+
+}
+
+func TestPathCacheConcurrent(t *testing.T) {
+
+	rng := rand.New(rand.NewSource(15))
+	m := randomGrid(rng, 30, 30, 0.2, true)
+	options := &PathOptions{Movement: MoveDiagonal, Heuristic: Octile}
+	cache := NewPathCache(m, 8)
+
+	pairs := make([][2]*Cell, 12)
+	for i := range pairs {
+		pairs[i] = [2]*Cell{randomCell(rng, m), randomCell(rng, m)}
+	}
+
+	done := make(chan struct{})
+	edits := sync.WaitGroup{}
+	edits.Add(1)
+
+	// While the cache is used, another goroutine keeps changing the Grid, locking it to do so.
+	go func() {
+		defer edits.Done()
+		rng := rand.New(rand.NewSource(16))
+		for {
+			select {
+			case <-done:
+				return
+			default:
+			}
+			m.Lock()
+			m.SetCellWalkable(m.Get(rng.Intn(30), rng.Intn(30)), rng.Intn(3) > 0)
+			m.Unlock()
+		}
+	}()
+
+	users := sync.WaitGroup{}
+	for u := 0; u < 8; u++ {
+		users.Add(1)
+		go func(u int) {
+			defer users.Done()
+			for i := 0; i < 50; i++ {
+				pair := pairs[(u*5+i)%len(pairs)]
+				result := cache.SearchFromCells(pair[0], pair[1], options)
+				if result.Err == nil && (result.Path.Cells[0] != pair[0] || result.Path.Cells[len(result.Path.Cells)-1] != pair[1]) {
+					t.Errorf("PathCache returned a Path from %s to %s for a route from %s to %s", result.Path.Cells[0], result.Path.Cells[len(result.Path.Cells)-1], pair[0], pair[1])
+				}
+			}
+		}(u)
+	}
+
+	users.Wait()
+	close(done)
+	edits.Wait()
+
+}
@@ -0,0 +1,210 @@
+package paths
+
+// A Rect is a rectangular region of Cells on a Grid, W Cells wide and H Cells high, with its top-left Cell at X, Y.
+type Rect struct {
+	X, Y, W, H int
+}
+
+// Contains returns whether the grid position provided lies within the Rect.
+func (r Rect) Contains(x, y int) bool {
+	return x >= r.X && y >= r.Y && x < r.X+r.W && y < r.Y+r.H
+}
+
+// Empty returns whether the Rect has no Cells in it.
+func (r Rect) Empty() bool {
+	return r.W <= 0 || r.H <= 0
+}
+
+// Union returns the smallest Rect containing both Rects.
+func (r Rect) Union(other Rect) Rect {
+	if r.Empty() {
+		return other
+	} else if other.Empty() {
+		return r
+	}
+	x, y := min(r.X, other.X), min(r.Y, other.Y)
+	return Rect{x, y, max(r.X+r.W, other.X+other.W) - x, max(r.Y+r.H, other.Y+other.H) - y}
+}
+
+// touches returns whether the Rects overlap or share an edge.
+func (r Rect) touches(other Rect) bool {
+	return r.X <= other.X+other.W && other.X <= r.X+r.W && r.Y <= other.Y+other.H && other.Y <= r.Y+r.H
+}
+
+// A GridChange describes a change to the walkability or Cost of some of the Cells in a Grid.
+type GridChange struct {
+	// Cells are the Cells that changed.
+	Cells []*Cell
+	// Bounds is the smallest Rect containing all of the Cells that changed.
+	Bounds Rect
+	// Version is the Grid's Version() after the change.
+	Version uint64
+}
+
+type subscriber struct {
+	id       int
+	callback func(GridChange)
+}
+
+// MarkChanged records that the Cells provided have changed. Call this after setting the Walkable or Cost fields of Cells directly
+// instead of through SetCellWalkable() or SetCellCost(). Each call bumps the Grid's Version(), adds to its DirtyRects(), and
+// notifies its subscribers once.
+func (m *Grid) MarkChanged(cells ...*Cell) {
+
+	if len(cells) == 0 {
+		return
+	}
+
+	if size := m.Width() * m.Height(); len(m.versions) != size {
+		m.versions = make([]uint64, size)
+	}
+
+	m.version++
+
+	change := GridChange{Cells: cells, Version: m.version}
+	for _, cell := range cells {
+		m.versions[cell.Y*m.Width()+cell.X] = m.version
+		change.Bounds = change.Bounds.Union(Rect{cell.X, cell.Y, 1, 1})
+	}
+
+	m.addDirty(change.Bounds)
+
+	for _, s := range m.subscribers {
+		s.callback(change)
+	}
+
+}
+
+// MarkRegionChanged records that the Cells within the Rect provided have changed, as with MarkChanged().
+func (m *Grid) MarkRegionChanged(region Rect) {
+	m.MarkChanged(m.regionCells(region)...)
+}
+
+// SetRegionWalkable sets the walkability of all of the Cells within the Rect provided, recording it as one change.
+func (m *Grid) SetRegionWalkable(region Rect, walkable bool) {
+	changed := []*Cell{}
+	for _, cell := range m.regionCells(region) {
+		if cell.Walkable != walkable {
+			cell.Walkable = walkable
+			changed = append(changed, cell)
+		}
+	}
+	m.MarkChanged(changed...)
+}
+
+// SetRegionCost sets the movement cost of all of the Cells within the Rect provided, recording it as one change.
+func (m *Grid) SetRegionCost(region Rect, cost float64) {
+	changed := []*Cell{}
+	for _, cell := range m.regionCells(region) {
+		if cell.Cost != cost {
+			cell.Cost = cost
+			changed = append(changed, cell)
+		}
+	}
+	m.MarkChanged(changed...)
+}
+
+// regionCells returns the Cells of the Grid within the Rect provided.
+func (m *Grid) regionCells(region Rect) []*Cell {
+	cells := []*Cell{}
+	for y := max(region.Y, 0); y < min(region.Y+region.H, m.Height()); y++ {
+		for x := max(region.X, 0); x < min(region.X+region.W, m.Width()); x++ {
+			cells = append(cells, m.Get(x, y))
+		}
+	}
+	return cells
+}
+
+// Version returns the number of changes recorded on the Grid so far. If it's the same as before, nothing has changed through
+// the Grid's setters (like SetCellWalkable() or SetCost()) or MarkChanged() in the meantime.
+func (m *Grid) Version() uint64 {
+	return m.version
+}
+
+// cellVersion returns the Version() of the Grid when the Cell provided last changed.
+func (m *Grid) cellVersion(cell *Cell) uint64 {
+	if i := cell.Y*m.Width() + cell.X; i < len(m.versions) {
+		return m.versions[i]
+	}
+	return 0
+}
+
+// addDirty adds the Rect provided to the dirty Rects, merging it with any it touches.
+func (m *Grid) addDirty(r Rect) {
+
+	for merged := true; merged; {
+		merged = false
+		for i, d := range m.dirty {
+			if d.touches(r) {
+				r = r.Union(d)
+				m.dirty[i] = m.dirty[len(m.dirty)-1]
+				m.dirty = m.dirty[:len(m.dirty)-1]
+				merged = true
+				break
+			}
+		}
+	}
+
+	m.dirty = append(m.dirty, r)
+
+}
+
+// DirtyRects returns the regions of the Grid that have changed since the last call to ClearDirty(). Touching or overlapping
+// regions are merged together.
+func (m *Grid) DirtyRects() []Rect {
+	return append([]Rect(nil), m.dirty...)
+}
+
+// ClearDirty clears the Grid's DirtyRects().
+func (m *Grid) ClearDirty() {
+	m.dirty = m.dirty[:0]
+}
+
+// Subscribe adds a callback that's called with each change made to the Grid (through its setters or MarkChanged()), right
+// after it's made. It returns a function that removes the callback again.
+func (m *Grid) Subscribe(callback func(change GridChange)) (unsubscribe func()) {
+
+	m.nextID++
+	id := m.nextID
+	m.subscribers = append(m.subscribers, subscriber{id, callback})
+
+	return func() {
+		for i, s := range m.subscribers {
+			if s.id == id {
+				// A new slice is made so that removing a subscriber while notifying them doesn't skip any.
+				m.subscribers = append(append([]subscriber{}, m.subscribers[:i]...), m.subscribers[i+1:]...)
+				return
+			}
+		}
+	}
+
+}
+
+// A watcher gives a structure built from a Grid (like a FlowField or a Hierarchy) Watch() and Unwatch(), so that it can keep
+// itself up to date as the Grid records changes. The structure embeds the watcher, and sets its target to itself.
+type watcher struct {
+	target  watchTarget
+	unwatch func()
+}
+
+// A watchTarget is a structure built from a Grid that can update the parts of itself affected by changes to the Grid's Cells.
+type watchTarget interface {
+	watchedGrid() *Grid
+	UpdateCells(cells ...*Cell)
+}
+
+// Watch subscribes to changes recorded on the Grid (see Grid.Subscribe()), so that they're passed to UpdateCells()
+// automatically. Call Unwatch() once it's no longer needed, so that the Grid lets go of it.
+func (w *watcher) Watch() {
+	if w.unwatch == nil {
+		w.unwatch = w.target.watchedGrid().Subscribe(func(change GridChange) { w.target.UpdateCells(change.Cells...) })
+	}
+}
+
+// Unwatch stops updating when the Grid changes.
+func (w *watcher) Unwatch() {
+	if w.unwatch != nil {
+		w.unwatch()
+		w.unwatch = nil
+	}
+}
@@ -0,0 +1,162 @@
+package paths
+
+// A ClearanceMap stores the clearance of each Cell in a Grid: the size of the largest square of walkable Cells that has the
+// Cell as its top-left corner (so a walkable Cell in a one-Cell-wide corridor has a clearance of 1, and a non-walkable Cell has
+// a clearance of 0). This lets agents bigger than a single Cell find Paths that they actually fit through; an agent that's 2x2
+// Cells in size can only stand where the clearance is at least 2.
+//
+// After changing the walkability of Cells, pass them to UpdateCells() to update the clearances affected by the change, or call
+// Watch() to have the ClearanceMap do that itself whenever the Grid records a change. A ClearanceMap assumes that the Grid
+// doesn't change size.
+type ClearanceMap struct {
+	Grid *Grid
+
+	values []int
+	watcher
+}
+
+// NewClearanceMap returns a new ClearanceMap for the Grid provided.
+func NewClearanceMap(grid *Grid) *ClearanceMap {
+	c := &ClearanceMap{Grid: grid}
+	c.target = c
+	c.Build()
+	return c
+}
+
+// Build computes the clearance of every Cell from scratch.
+func (c *ClearanceMap) Build() {
+
+	w, h := c.Grid.Width(), c.Grid.Height()
+	if len(c.values) != w*h {
+		c.values = make([]int, w*h)
+	}
+
+	// Each Cell's clearance depends on the Cells to its right and below it, so we work backwards from the bottom-right.
+	for y := h - 1; y >= 0; y-- {
+		for x := w - 1; x >= 0; x-- {
+			c.values[y*w+x] = c.compute(x, y)
+		}
+	}
+
+}
+
+// compute returns the clearance of the Cell at the position provided from the stored clearances of its neighbors to the right
+// and below it.
+func (c *ClearanceMap) compute(x, y int) int {
+	if !c.Grid.walkable(x, y) {
+		return 0
+	}
+	return 1 + min(c.Clearance(x+1, y), min(c.Clearance(x, y+1), c.Clearance(x+1, y+1)))
+}
+
+// UpdateCells updates the ClearanceMap after changes to the walkability of the Cells provided. A Cell changing only affects
+// the Cells above and to the left of it, and only as far out as their clearances actually change.
+func (c *ClearanceMap) UpdateCells(cells ...*Cell) {
+
+	w := c.Grid.Width()
+
+	for _, cell := range cells {
+
+		// Cells are recomputed in bands around the changed Cell, each one a step further up and to the left. Each band only
+		// depends on the band before it and on itself, so once a band doesn't change, neither will the ones past it.
+		for k := 0; k <= cell.X || k <= cell.Y; k++ {
+
+			changed := false
+
+			update := func(x, y int) {
+				if x < 0 || y < 0 {
+					return
+				}
+				if v := c.compute(x, y); v != c.values[y*w+x] {
+					c.values[y*w+x] = v
+					changed = true
+				}
+			}
+
+			// The left side of the band, from the bottom up, then the top side from the right, ending at the corner.
+			for j := 0; j < k; j++ {
+				update(cell.X-k, cell.Y-j)
+			}
+			for i := 0; i < k; i++ {
+				update(cell.X-i, cell.Y-k)
+			}
+			update(cell.X-k, cell.Y-k)
+
+			if !changed {
+				break
+			}
+
+		}
+
+	}
+
+}
+
+func (c *ClearanceMap) watchedGrid() *Grid {
+	return c.Grid
+}
+
+// Clearance returns the clearance of the Cell at the grid position provided, or 0 if it's outside of the Grid.
+func (c *ClearanceMap) Clearance(x, y int) int {
+	if c.Grid.Get(x, y) == nil {
+		return 0
+	}
+	return c.values[y*c.Grid.Width()+x]
+}
+
+// Fits returns whether an agent size Cells wide and tall fits with its top-left corner on the Cell provided.
+func (c *ClearanceMap) Fits(cell *Cell, size int) bool {
+	return cell != nil && c.Clearance(cell.X, cell.Y) >= size
+}
+
+// GetPathFromCells returns a Path for an agent size Cells wide and tall, from the starting Cell to the destination Cell. Each
+// Cell of the Path is where the agent's top-left corner goes; the rest of the agent covers the Cells to the right and below it,
+// all of which are walkable. Stepping onto a Cell costs the Cell's Cost, as usual. options controls how the Path is found, as
+// with Grid.GetPathFromCells(); diagonal steps are only taken if the agent fits on the Cells beside them, unless CornerCutting
+// allows otherwise. GetPathFromCells returns nil if the agent doesn't fit at either end, and an empty Path if no Path could be
+// found.
+func (c *ClearanceMap) GetPathFromCells(start, dest *Cell, size int, options *PathOptions) *Path {
+	return pathFromResult(c.SearchFromCells(start, dest, size, options))
+}
+
+// GetPath returns a Path for an agent size Cells wide and tall, from the starting world X and Y position to the ending X and Y
+// position, as with GetPathFromCells(). The positions are those of the agent's top-left Cell.
+func (c *ClearanceMap) GetPath(startX, startY, endX, endY float64, size int, options *PathOptions) *Path {
+	return pathFromResult(c.Search(startX, startY, endX, endY, size, options))
+}
+
+// Search searches for a Path for an agent size Cells wide and tall from the starting world X and Y position to the ending X and
+// Y position, as with GetPath(), and returns the result.
+func (c *ClearanceMap) Search(startX, startY, endX, endY float64, size int, options *PathOptions) *PathResult {
+	sx, sy := c.Grid.WorldToGrid(startX, startY)
+	ex, ey := c.Grid.WorldToGrid(endX, endY)
+	return c.SearchFromCells(c.Grid.Get(sx, sy), c.Grid.Get(ex, ey), size, options)
+}
+
+// SearchFromCells searches for a Path for an agent size Cells wide and tall from the starting Cell to the destination Cell, as
+// with GetPathFromCells(), and returns the result. If the agent doesn't fit at the start, the result's Err is ErrStartBlocked;
+// if it doesn't fit at the destination, it's ErrDestBlocked.
+func (c *ClearanceMap) SearchFromCells(start, dest *Cell, size int, options *PathOptions) *PathResult {
+
+	if start == nil || dest == nil {
+		return &PathResult{SearchStats: SearchStats{Err: ErrOutOfBounds}}
+	} else if !c.Fits(start, size) {
+		return &PathResult{SearchStats: SearchStats{Err: ErrStartBlocked}}
+	} else if !c.Fits(dest, size) && !options.allowPartial() {
+		return &PathResult{SearchStats: SearchStats{Err: ErrDestBlocked}}
+	}
+
+	sized := PathOptions{}
+	if options != nil {
+		sized = *options
+	}
+
+	// The agent's own PassableFunc (if it has one) still gets a say, too.
+	passable := sized.PassableFunc
+	sized.PassableFunc = func(from, to *Cell, agent interface{}) bool {
+		return c.Fits(to, size) && (passable == nil || passable(from, to, agent))
+	}
+
+	return c.Grid.SearchFromCells(start, dest, &sized)
+
+}
@@ -0,0 +1,40 @@
+package paths
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestClearanceMapUpdateMatchesBuild(t *testing.T) {
+
+	rng := rand.New(rand.NewSource(9))
+
+	for i := 0; i < 30; i++ {
+
+		m := randomGrid(rng, 5+rng.Intn(30), 5+rng.Intn(30), rng.Float64()*0.3, false)
+		w, h := m.Width(), m.Height()
+
+		// Changes at the Grid's edges and corners cut the bands of Cells that are updated short, while a wall right across the
+		// Grid changes the clearance of everything above it.
+		edits := []gridEdit{
+			{Rect{X: 0, Y: 0, W: 1, H: 1}, false, 1},
+			{Rect{X: w - 1, Y: h - 1, W: 1, H: 1}, false, 1},
+			{Rect{X: w - 1, Y: 0, W: 1, H: h}, false, 1},
+			{Rect{X: 0, Y: h / 2, W: w, H: 1}, false, 1},
+			{Rect{X: 0, Y: 0, W: w, H: h}, true, 1},
+		}
+		for j := 0; j < 30; j++ {
+			edits = append(edits, randomEdit(rng, m, 4))
+		}
+
+		testUpdates(m, i%2 == 0, edits, func() updater { return NewClearanceMap(m) }, func(got, want updater) {
+			for _, cell := range m.AllCells() {
+				if g, w := got.(*ClearanceMap).Clearance(cell.X, cell.Y), want.(*ClearanceMap).Clearance(cell.X, cell.Y); g != w {
+					t.Fatalf("updated ClearanceMap has a clearance of %d at %s, but a rebuilt one has %d", g, cell, w)
+				}
+			}
+		})
+
+	}
+
+}
@@ -0,0 +1,367 @@
+package paths
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// CostLayer controls how a CompactGrid stores the Cost of its Cells.
+type CostLayer int
+
+const (
+	// NoCosts stores no Costs at all; every Cell has a Cost of 1.
+	NoCosts CostLayer = iota
+	// Costs8 stores a Cost from 0 to 255 for each Cell, using one byte per Cell.
+	Costs8
+	// Costs16 stores a Cost from 0 to 65535 for each Cell, using two bytes per Cell.
+	Costs16
+)
+
+// A Point is a position on a grid.
+type Point struct {
+	X, Y int
+}
+
+// A CompactGrid is a Grid for very large maps that stores only what pathfinding needs: one bit per Cell for walkability, and
+// optionally one or two bytes per Cell for its Cost (see CostLayer). Rather than *Cells, a CompactGrid works with grid
+// positions, and its Paths are lists of Points.
+//
+// A CompactGrid can be searched from multiple goroutines at once, but not while it's being changed.
+type CompactGrid struct {
+	CellWidth, CellHeight int
+
+	width, height int
+	walkable      []uint64
+	costs8        []uint8
+	costs16       []uint16
+	searches      sync.Pool
+}
+
+// NewCompactGrid returns a new CompactGrid of (gridWidth x gridHeight) size, with all Cells walkable and a Cost of 1. cellWidth
+// and cellHeight are the size of each Cell in the world, as with NewGrid(). layer controls how Costs are stored.
+func NewCompactGrid(gridWidth, gridHeight, cellWidth, cellHeight int, layer CostLayer) *CompactGrid {
+
+	size := gridWidth * gridHeight
+
+	g := &CompactGrid{
+		CellWidth:  cellWidth,
+		CellHeight: cellHeight,
+		width:      gridWidth,
+		height:     gridHeight,
+		walkable:   make([]uint64, (size+63)/64),
+	}
+
+	for i := range g.walkable {
+		g.walkable[i] = math.MaxUint64
+	}
+
+	switch layer {
+	case Costs8:
+		g.costs8 = make([]uint8, size)
+		for i := range g.costs8 {
+			g.costs8[i] = 1
+		}
+	case Costs16:
+		g.costs16 = make([]uint16, size)
+		for i := range g.costs16 {
+			g.costs16[i] = 1
+		}
+	}
+
+	return g
+
+}
+
+// NewCompactGridFromGrid returns a new CompactGrid with the same size, walkability, and Costs as the Grid provided. Costs are
+// rounded to the nearest whole number that fits in the CostLayer given.
+func NewCompactGridFromGrid(grid *Grid, layer CostLayer) *CompactGrid {
+
+	g := NewCompactGrid(grid.Width(), grid.Height(), grid.CellWidth, grid.CellHeight, layer)
+
+	for _, cell := range grid.AllCells() {
+		g.SetWalkable(cell.X, cell.Y, cell.Walkable)
+		g.SetCost(cell.X, cell.Y, int(math.Round(cell.Cost)))
+	}
+
+	return g
+
+}
+
+// Width returns the width of the CompactGrid.
+func (g *CompactGrid) Width() int {
+	return g.width
+}
+
+// Height returns the height of the CompactGrid.
+func (g *CompactGrid) Height() int {
+	return g.height
+}
+
+// Contains returns whether the grid position provided lies within the CompactGrid.
+func (g *CompactGrid) Contains(x, y int) bool {
+	return x >= 0 && y >= 0 && x < g.width && y < g.height
+}
+
+// Walkable returns whether the Cell at the grid position provided is walkable. Positions outside of the CompactGrid aren't.
+func (g *CompactGrid) Walkable(x, y int) bool {
+	if !g.Contains(x, y) {
+		return false
+	}
+	i := y*g.width + x
+	return g.walkable[i/64]&(1<<uint(i%64)) != 0
+}
+
+// SetWalkable sets whether the Cell at the grid position provided is walkable.
+func (g *CompactGrid) SetWalkable(x, y int, walkable bool) {
+	if !g.Contains(x, y) {
+		return
+	}
+	i := y*g.width + x
+	if walkable {
+		g.walkable[i/64] |= 1 << uint(i%64)
+	} else {
+		g.walkable[i/64] &^= 1 << uint(i%64)
+	}
+}
+
+// SetRegionWalkable sets whether all of the Cells within the Rect provided are walkable.
+func (g *CompactGrid) SetRegionWalkable(region Rect, walkable bool) {
+	for y := max(region.Y, 0); y < min(region.Y+region.H, g.height); y++ {
+		for x := max(region.X, 0); x < min(region.X+region.W, g.width); x++ {
+			g.SetWalkable(x, y, walkable)
+		}
+	}
+}
+
+// Cost returns the Cost of the Cell at the grid position provided. Without a CostLayer, every Cell has a Cost of 1. Positions
+// outside of the CompactGrid have a Cost of positive infinity.
+func (g *CompactGrid) Cost(x, y int) float64 {
+	if !g.Contains(x, y) {
+		return math.Inf(1)
+	}
+	return g.cost(y*g.width + x)
+}
+
+func (g *CompactGrid) cost(i int) float64 {
+	if g.costs8 != nil {
+		return float64(g.costs8[i])
+	} else if g.costs16 != nil {
+		return float64(g.costs16[i])
+	}
+	return 1
+}
+
+// SetCost sets the Cost of the Cell at the grid position provided, clamped to the range the CompactGrid's CostLayer can store.
+// Without a CostLayer, SetCost does nothing.
+func (g *CompactGrid) SetCost(x, y int, cost int) {
+	if !g.Contains(x, y) {
+		return
+	}
+	i := y*g.width + x
+	if g.costs8 != nil {
+		g.costs8[i] = uint8(max(0, min(cost, math.MaxUint8)))
+	} else if g.costs16 != nil {
+		g.costs16[i] = uint16(max(0, min(cost, math.MaxUint16)))
+	}
+}
+
+// GridToWorld converts from a grid position to world position, as with Grid.GridToWorld().
+func (g *CompactGrid) GridToWorld(x, y int) (float64, float64) {
+	return float64(x * g.CellWidth), float64(y * g.CellHeight)
+}
+
+// WorldToGrid converts from a world position to a grid position, as with Grid.WorldToGrid().
+func (g *CompactGrid) WorldToGrid(x, y float64) (int, int) {
+	return int(math.Floor(x / float64(g.CellWidth))), int(math.Floor(y / float64(g.CellHeight)))
+}
+
+// A PointResult is the outcome of a search on a CompactGrid, along with some statistics about it. It's the same as a
+// PathResult, other than the Path being a list of Points.
+type PointResult struct {
+	// Points are the grid positions along the Path found, from the start to the destination, or nil if Err is set.
+	Points []Point
+	SearchStats
+}
+
+// GetPath returns the grid positions along a Path from the starting grid position to the destination. options controls how
+// the Path is found, as with Grid.GetPathFromCells(), though Algorithm is ignored (CompactGrids are always searched with A*).
+// The Cells handed to the options' PassableFunc, CostFunc, and Heuristic only have their positions, Costs, and walkability
+// set, and are reused between calls, so they shouldn't be held on to. GetPath returns nil if no Path was found.
+func (g *CompactGrid) GetPath(startX, startY, destX, destY int, options *PathOptions) []Point {
+	return g.Search(startX, startY, destX, destY, options).Points
+}
+
+// Search searches for a Path from the starting grid position to the destination, as with GetPath(), and returns the result.
+func (g *CompactGrid) Search(startX, startY, destX, destY int, options *PathOptions) *PointResult {
+
+	began := time.Now()
+
+	if !g.Contains(startX, startY) || !g.Contains(destX, destY) {
+		return &PointResult{SearchStats: SearchStats{Err: ErrOutOfBounds}}
+	} else if !g.Walkable(startX, startY) {
+		return &PointResult{SearchStats: SearchStats{Err: ErrStartBlocked}}
+	} else if !g.Walkable(destX, destY) && !options.allowPartial() {
+		return &PointResult{SearchStats: SearchStats{Err: ErrDestBlocked}}
+	}
+
+	s, ok := g.searches.Get().(*indexSearch)
+	if !ok {
+		s = &indexSearch{}
+	}
+	found := s.search(g.Graph(options), startY*g.width+startX, destY*g.width+destX, options)
+	g.searches.Put(s)
+
+	result := &PointResult{SearchStats: found.SearchStats}
+	if found.Nodes != nil {
+		result.Points = make([]Point, len(found.Nodes))
+		for i, n := range found.Nodes {
+			result.Points[i] = Point{n % g.width, n / g.width}
+		}
+	}
+	result.Duration = time.Since(began)
+	return result
+
+}
+
+// Nodes returns the number of Cells in the CompactGrid, so that a CompactGrid can be used as a Graph. Each Cell's node is its
+// index (Y * Width + X).
+func (g *CompactGrid) Nodes() int {
+	return g.width * g.height
+}
+
+// AppendEdges appends the Edges leading from the Cell at the index provided to its walkable neighbors, moving orthogonally.
+// This lets a CompactGrid be used as a Graph; for other kinds of movement, use Graph() instead.
+func (g *CompactGrid) AppendEdges(edges []Edge, node int) []Edge {
+	return (&compactGraph{grid: g}).AppendEdges(edges, node)
+}
+
+// Estimate estimates the cost of moving between the Cells at the indices provided using the Manhattan Heuristic, so that a
+// CompactGrid can be used as a Graph.
+func (g *CompactGrid) Estimate(from, to int) float64 {
+	return float64(abs(to%g.width-from%g.width) + abs(to/g.width-from/g.width))
+}
+
+func (g *CompactGrid) startCost(node int) float64 {
+	return g.cost(node)
+}
+
+// Graph returns the CompactGrid as a Graph whose Edges follow the PathOptions provided, as they would when searching the
+// CompactGrid itself. The Graph's Estimate uses the options' Heuristic (or 0, if there isn't one). The Graph isn't safe to
+// search from multiple goroutines at once; call Graph() once for each goroutine.
+func (g *CompactGrid) Graph(options *PathOptions) Graph {
+	return &compactGraph{grid: g, options: options}
+}
+
+// compactGraph is a CompactGrid searched using a particular set of PathOptions.
+type compactGraph struct {
+	grid    *CompactGrid
+	options *PathOptions
+	// from and to are handed to Heuristics, PassableFunc, and CostFunc, which expect Cells. Only their positions, Costs, and
+	// walkability are set.
+	from, to Cell
+}
+
+func (c *compactGraph) Nodes() int {
+	return c.grid.Nodes()
+}
+
+func (c *compactGraph) startCost(node int) float64 {
+	return c.grid.cost(node)
+}
+
+func (c *compactGraph) AppendEdges(edges []Edge, node int) []Edge {
+
+	g := c.grid
+	x, y := node%g.width, node/g.width
+
+	left := c.open(node, x-1, y)
+	right := c.open(node, x+1, y)
+	up := c.open(node, x, y-1)
+	down := c.open(node, x, y+1)
+
+	for _, d := range [4]struct {
+		x, y int
+		open bool
+	}{{x - 1, y, left}, {x + 1, y, right}, {x, y - 1, up}, {x, y + 1, down}} {
+		if d.open {
+			i := d.y*g.width + d.x
+			edges = c.appendEdge(edges, node, i, g.cost(i))
+		}
+	}
+
+	// Do the same thing for diagonals, following the same corner rules as a Grid.
+	if c.options.diagonals() {
+
+		corners := c.options.cornerCutting()
+
+		for _, d := range [4]struct {
+			x, y int
+			a, b bool
+		}{
+			{x - 1, y - 1, left, up},
+			{x + 1, y - 1, right, up},
+			{x - 1, y + 1, left, down},
+			{x + 1, y + 1, right, down},
+		} {
+			if !c.open(node, d.x, d.y) {
+				continue
+			}
+			if (corners == NoCornerCutting && (!d.a || !d.b)) || (corners == CornerCuttingOneWall && !d.a && !d.b) {
+				continue
+			}
+			i := d.y*g.width + d.x
+			edges = c.appendEdge(edges, node, i, g.cost(i)+c.options.diagonalCost())
+		}
+
+	}
+
+	return edges
+
+}
+
+// open returns whether the Cell at the grid position provided is walkable, and the options' PassableFunc lets it be stepped onto
+// from the node given.
+func (c *compactGraph) open(from, x, y int) bool {
+	if !c.grid.Walkable(x, y) {
+		return false
+	}
+	if c.options.custom() {
+		c.setCells(from, y*c.grid.width+x)
+		return c.options.passable(&c.from, &c.to)
+	}
+	return true
+}
+
+// appendEdge appends an Edge from one node to another with the cost provided, as changed by the options' CostFunc.
+func (c *compactGraph) appendEdge(edges []Edge, from, to int, cost float64) []Edge {
+	if c.options.custom() {
+		c.setCells(from, to)
+		if cost = c.options.stepCost(&c.from, &c.to, cost); math.IsInf(cost, 1) {
+			return edges
+		}
+	}
+	return append(edges, Edge{to, cost})
+}
+
+func (c *compactGraph) Estimate(from, to int) float64 {
+	h := c.options.heuristic()
+	if h == nil {
+		return 0
+	}
+	c.setCells(from, to)
+	return h.Estimate(&c.from, &c.to)
+}
+
+func (c *compactGraph) partialScore(node, dest int) float64 {
+	c.setCells(node, dest)
+	return c.options.partialScore(&c.from, &c.to)
+}
+
+func (c *compactGraph) setCells(from, to int) {
+	w := c.grid.width
+	c.from.X, c.from.Y, c.from.Cost = from%w, from/w, c.grid.cost(from)
+	c.from.Walkable = c.grid.Walkable(c.from.X, c.from.Y)
+	c.to.X, c.to.Y, c.to.Cost = to%w, to/w, c.grid.cost(to)
+	c.to.Walkable = c.grid.Walkable(c.to.X, c.to.Y)
+}
@@ -0,0 +1,66 @@
+package paths
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func TestCompactGridCustomOptions(t *testing.T) {
+
+	rng := rand.New(rand.NewSource(6))
+
+	// Boats can only cross the Cells with even Costs, and pay double for heading up.
+	options := &PathOptions{
+		Movement: MoveDiagonal,
+		Agent:    "boat",
+		PassableFunc: func(from, to *Cell, agent interface{}) bool {
+			return agent == "boat" && int(to.Cost)%2 == 0
+		},
+		CostFunc: func(from, to *Cell, cost float64, agent interface{}) float64 {
+			if to.Y < from.Y {
+				return cost * 2
+			}
+			return cost
+		},
+	}
+
+	for i := 0; i < 10; i++ {
+
+		m := randomGrid(rng, 30, 20, 0.1, true)
+		compact := NewCompactGridFromGrid(m, Costs8)
+
+		for j := 0; j < 20; j++ {
+			start, dest := randomCell(rng, m), randomCell(rng, m)
+			want := m.SearchFromCells(start, dest, options)
+			got := compact.Search(start.X, start.Y, dest.X, dest.Y, options)
+			if got.Err != want.Err || (want.Err == nil && got.Cost != want.Cost) {
+				t.Fatalf("CompactGrid found %v (cost %f), but the Grid found %v (cost %f)", got.Err, got.Cost, want.Err, want.Cost)
+			}
+		}
+
+	}
+
+}
+
+func TestCompactGridOutOfBounds(t *testing.T) {
+
+	for _, layer := range []CostLayer{NoCosts, Costs8, Costs16} {
+
+		compact := NewCompactGrid(4, 3, 16, 16, layer)
+
+		for _, p := range [][2]int{{-1, 0}, {0, -1}, {4, 0}, {0, 3}, {-5, 10}} {
+			compact.SetWalkable(p[0], p[1], true)
+			compact.SetCost(p[0], p[1], 2)
+			if compact.Walkable(p[0], p[1]) || !math.IsInf(compact.Cost(p[0], p[1]), 1) {
+				t.Errorf("position %v outside of the CompactGrid should be unwalkable with an infinite Cost", p)
+			}
+		}
+
+		if compact.Cost(3, 2) != 1 {
+			t.Errorf("Cost at the CompactGrid's corner should be 1, not %f", compact.Cost(3, 2))
+		}
+
+	}
+
+}
@@ -0,0 +1,97 @@
+package paths
+
+import "math"
+
+// SetEdgeCost sets the cost of stepping from one Cell onto a neighboring one, in that direction only, in place of the Cost of
+// the Cell being stepped onto (and any DiagonalCost). A cost of positive infinity (math.Inf(1)) stops the step from being taken
+// at all; see also SetEdgePassable(). This can be used for things like one-way doors, conveyor belts, or ledges that can be
+// dropped off of but not climbed back up. If the Cells aren't neighbors (including diagonally), or either isn't one of this Grid's
+// Cells, SetEdgeCost does nothing.
+func (m *Grid) SetEdgeCost(from, to *Cell, cost float64) {
+	if !m.neighboring(from, to) {
+		return
+	}
+	if m.edgeCosts == nil {
+		m.edgeCosts = map[[2]int]float64{}
+	}
+	m.edgeCosts[m.edgeKey(from, to)] = cost
+	m.MarkChanged(from, to)
+}
+
+// SetEdgePassable sets whether the step from one Cell onto a neighboring one can be taken, in that direction only. A passable
+// step has its usual cost. As with SetEdgeCost(), Cells that aren't neighbors on this Grid are ignored.
+func (m *Grid) SetEdgePassable(from, to *Cell, passable bool) {
+	if passable {
+		m.ClearEdgeCost(from, to)
+	} else {
+		m.SetEdgeCost(from, to, math.Inf(1))
+	}
+}
+
+// ClearEdgeCost removes the edge cost set for stepping from one Cell onto a neighboring one, if there is one.
+func (m *Grid) ClearEdgeCost(from, to *Cell) {
+	if !m.neighboring(from, to) {
+		return
+	}
+	key := m.edgeKey(from, to)
+	if _, ok := m.edgeCosts[key]; ok {
+		delete(m.edgeCosts, key)
+		if len(m.edgeCosts) == 0 {
+			m.edgeCosts = nil
+		}
+		m.MarkChanged(from, to)
+	}
+}
+
+// ClearEdgeCosts removes all edge costs from the Grid.
+func (m *Grid) ClearEdgeCosts() {
+	cells := []*Cell{}
+	for key := range m.edgeCosts {
+		cells = append(cells, m.GetIndex(key[0]), m.GetIndex(key[1]))
+	}
+	m.edgeCosts = nil
+	m.MarkChanged(cells...)
+}
+
+// EdgeCost returns the cost of stepping from one Cell onto a neighboring one with the PathOptions provided, taking edge costs,
+// the Grid's EdgeCostFunc, and the options' PassableFunc and CostFunc into account. If the step can't be taken, EdgeCost returns
+// positive infinity.
+func (m *Grid) EdgeCost(from, to *Cell, options *PathOptions) float64 {
+	return m.stepCost(from, to, options)
+}
+
+// edgeCost returns the cost of stepping from one Cell onto a neighboring one, given the cost the step would normally have, after
+// applying any edge cost and the Grid's EdgeCostFunc.
+func (m *Grid) edgeCost(from, to *Cell, cost float64) float64 {
+
+	if m.edgeCosts != nil {
+		if c, ok := m.edgeCosts[m.edgeKey(from, to)]; ok {
+			cost = c
+		}
+	}
+
+	if m.EdgeCostFunc != nil {
+		cost = m.EdgeCostFunc(from, to, cost)
+	}
+
+	return cost
+
+}
+
+// neighboring returns whether the Cells provided are both this Grid's own, and next to each other (including diagonally).
+func (m *Grid) neighboring(from, to *Cell) bool {
+	if from == nil || to == nil || from == to || m.Get(from.X, from.Y) != from || m.Get(to.X, to.Y) != to {
+		return false
+	}
+	return abs(from.X-to.X) <= 1 && abs(from.Y-to.Y) <= 1
+}
+
+func (m *Grid) edgeKey(from, to *Cell) [2]int {
+	return [2]int{m.Index(from.X, from.Y), m.Index(to.X, to.Y)}
+}
+
+// directed returns whether stepping between Cells might cost something other than the Cost of the Cell being stepped onto,
+// depending on direction.
+func (m *Grid) directed() bool {
+	return m.edgeCosts != nil || m.EdgeCostFunc != nil
+}
@@ -80,7 +80,7 @@ func (pd *PathDrawer) Draw() {
 
 	if tc != nil {
 
-		newPath := pd.World.GameMap.GetPathFromCells(sc, tc, false)
+		newPath := pd.World.GameMap.GetPathFromCells(sc, tc, nil)
 		if !newPath.Same(pd.Path) {
 			pd.Path = newPath
 		}
@@ -0,0 +1,269 @@
+package paths
+
+import "math"
+
+// DefaultFleeMultiplier is a good starting multiplier for FlowField.Flee(). Multipliers further below -1 make fleeing agents
+// more willing to run past the goals to reach a better escape route.
+const DefaultFleeMultiplier = -1.2
+
+// A FlowField stores, for every Cell in a Grid, the cost of reaching the nearest (or cheapest) of one or more goal Cells, along
+// with the direction to move in to get there. This makes it ideal for many agents heading to the same place: rather than
+// finding a Path for each agent, they each just look up the direction to move in from where they are.
+//
+// A FlowField is built when Build() is called. After changing the walkability or Cost of Cells, pass them to UpdateCells()
+// (or UpdateRegion()) to update just the parts of the FlowField affected by the change, or call Watch() to have the FlowField
+// do that itself whenever the Grid records a change. A FlowField assumes that the Grid doesn't change size.
+type FlowField struct {
+	Grid    *Grid
+	Options *PathOptions
+
+	// seeds are the starting costs of the Cells the field flows towards, by index.
+	seeds map[int]float64
+	costs []float64
+	next  []int
+
+	queue     indexHeap
+	neighbors []neighbor
+	watcher
+}
+
+// NewFlowField returns a new FlowField for the Grid provided, flowing towards the goal Cells given (which can be added to later
+// using AddGoal()). options controls which Cells can be moved between, and how much moving between them costs; options like
+// Heuristic and Algorithm don't apply.
+func NewFlowField(grid *Grid, options *PathOptions, goals ...*Cell) *FlowField {
+
+	f := &FlowField{
+		Grid:    grid,
+		Options: options,
+		seeds:   map[int]float64{},
+	}
+	f.target = f
+
+	for _, goal := range goals {
+		f.AddGoal(goal, 0)
+	}
+
+	f.Build()
+	return f
+
+}
+
+// AddGoal adds a goal Cell to the FlowField. weight is the cost of reaching the goal itself, so goals with higher weights are
+// less attractive than those with lower ones. Call Build() afterwards to update the FlowField.
+func (f *FlowField) AddGoal(goal *Cell, weight float64) {
+	f.seeds[f.index(goal)] = weight
+}
+
+// ClearGoals removes all goals from the FlowField. Call Build() afterwards to update the FlowField.
+func (f *FlowField) ClearGoals() {
+	f.seeds = map[int]float64{}
+}
+
+// Build builds the entire FlowField from scratch.
+func (f *FlowField) Build() {
+
+	size := f.Grid.Width() * f.Grid.Height()
+	if len(f.costs) != size {
+		f.costs = make([]float64, size)
+		f.next = make([]int, size)
+	}
+
+	for i := range f.costs {
+		f.costs[i] = math.Inf(1)
+		f.next[i] = -1
+	}
+
+	f.queue.items = f.queue.items[:0]
+	f.seed(nil)
+	f.flow()
+
+}
+
+// Flee returns a new FlowField that flows away from the goals of this one, rather than towards them. The costs of this
+// FlowField are scaled by the multiplier provided (which should be negative; DefaultFleeMultiplier is a good start), and then
+// flowed out again, so that agents head for open areas rather than simply the farthest corner. Unreachable Cells are left out.
+func (f *FlowField) Flee(multiplier float64) *FlowField {
+
+	flee := &FlowField{
+		Grid:    f.Grid,
+		Options: f.Options,
+		seeds:   map[int]float64{},
+	}
+	flee.target = flee
+
+	for i, cost := range f.costs {
+		if !math.IsInf(cost, 1) {
+			flee.seeds[i] = cost * multiplier
+		}
+	}
+
+	flee.Build()
+	return flee
+
+}
+
+// UpdateRegion updates the FlowField after changes to the walkability or Cost of any Cells in the rectangle provided.
+func (f *FlowField) UpdateRegion(x, y, w, h int) {
+
+	cells := []*Cell{}
+	for cy := y; cy < y+h; cy++ {
+		for cx := x; cx < x+w; cx++ {
+			if c := f.Grid.Get(cx, cy); c != nil {
+				cells = append(cells, c)
+			}
+		}
+	}
+	f.UpdateCells(cells...)
+
+}
+
+// UpdateCells updates the FlowField after changes to the walkability or Cost of the Cells provided. Only the Cells whose
+// route passes through the changes, and those that could now take a cheaper route through them, are updated.
+func (f *FlowField) UpdateCells(cells ...*Cell) {
+
+	w := f.Grid.Width()
+
+	// Every Cell routed through the Cells affected by the change has to be redone.
+	invalid := map[int]bool{}
+	stack := []int{}
+
+	for _, cell := range f.Grid.appendAffected(nil, cells) {
+		if i := f.index(cell); !invalid[i] {
+			invalid[i] = true
+			stack = append(stack, i)
+		}
+	}
+
+	for len(stack) > 0 {
+
+		i := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+
+		for y := i/w - 1; y <= i/w+1; y++ {
+			for x := i%w - 1; x <= i%w+1; x++ {
+				if f.Grid.Get(x, y) != nil && f.next[y*w+x] == i && !invalid[y*w+x] {
+					invalid[y*w+x] = true
+					stack = append(stack, y*w+x)
+				}
+			}
+		}
+
+	}
+
+	for i := range invalid {
+		f.costs[i] = math.Inf(1)
+		f.next[i] = -1
+	}
+
+	// Then, everything around the invalidated Cells flows back into them.
+	f.queue.items = f.queue.items[:0]
+	f.seed(invalid)
+
+	for i := range invalid {
+		for y := i/w - 1; y <= i/w+1; y++ {
+			for x := i%w - 1; x <= i%w+1; x++ {
+				if n := y*w + x; f.Grid.Get(x, y) != nil && !invalid[n] && !math.IsInf(f.costs[n], 1) {
+					f.queue.push(indexItem{slot: int32(n), cost: f.costs[n]})
+				}
+			}
+		}
+	}
+
+	f.flow()
+
+}
+
+func (f *FlowField) watchedGrid() *Grid {
+	return f.Grid
+}
+
+// seed resets the seeds to their starting costs and queues them. If only is non-nil, only the seeds in it are reset.
+func (f *FlowField) seed(only map[int]bool) {
+	w := f.Grid.Width()
+	for i, cost := range f.seeds {
+		if (only == nil || only[i]) && f.Grid.walkable(i%w, i/w) {
+			f.costs[i] = cost
+			f.next[i] = -1
+			f.queue.push(indexItem{slot: int32(i), cost: cost})
+		}
+	}
+}
+
+// flow spreads the costs of the queued Cells out to the Cells that can move onto them, until there's nothing cheaper left to
+// find.
+func (f *FlowField) flow() {
+
+	w := f.Grid.Width()
+
+	for f.queue.Len() > 0 {
+
+		item := f.queue.pop()
+		index := int(item.slot)
+		if item.cost > f.costs[index] {
+			continue
+		}
+
+		cell := f.Grid.Get(index%w, index/w)
+
+		f.neighbors = f.Grid.appendPredecessors(f.neighbors[:0], cell, f.Options)
+		for _, n := range f.neighbors {
+			ni := f.index(n.Cell)
+			if cost := item.cost + n.Cost; cost < f.costs[ni] {
+				f.costs[ni] = cost
+				f.next[ni] = index
+				f.queue.push(indexItem{slot: int32(ni), cost: cost})
+			}
+		}
+
+	}
+
+}
+
+func (f *FlowField) index(cell *Cell) int {
+	return cell.Y*f.Grid.Width() + cell.X
+}
+
+// Cost returns the cost of reaching a goal from the Cell at the grid position provided. If no goal can be reached from there
+// (or the position is outside of the Grid), it returns positive infinity.
+func (f *FlowField) Cost(x, y int) float64 {
+	if f.Grid.Get(x, y) == nil {
+		return math.Inf(1)
+	}
+	return f.costs[y*f.Grid.Width()+x]
+}
+
+// CostAtWorld returns the cost of reaching a goal from the world position provided, as with Cost().
+func (f *FlowField) CostAtWorld(x, y float64) float64 {
+	return f.Cost(f.Grid.WorldToGrid(x, y))
+}
+
+// Next returns the neighboring Cell to move to from the Cell provided to best reach a goal. If the Cell is a goal itself, or
+// no goal can be reached from it, Next returns nil.
+func (f *FlowField) Next(cell *Cell) *Cell {
+	if cell == nil || f.Grid.Get(cell.X, cell.Y) != cell {
+		return nil
+	}
+	if next := f.next[f.index(cell)]; next >= 0 {
+		return f.Grid.Get(next%f.Grid.Width(), next/f.Grid.Width())
+	}
+	return nil
+}
+
+// Direction returns the direction to move in (each of X and Y being -1, 0, or 1) from the Cell at the grid position provided
+// to best reach a goal. If the Cell is a goal itself, or no goal can be reached from it, Direction returns 0, 0.
+func (f *FlowField) Direction(x, y int) (int, int) {
+	if next := f.Next(f.Grid.Get(x, y)); next != nil {
+		return next.X - x, next.Y - y
+	}
+	return 0, 0
+}
+
+// DirectionAtWorld returns the normalized direction to move in from the world position provided to best reach a goal, as
+// with Direction().
+func (f *FlowField) DirectionAtWorld(x, y float64) (float64, float64) {
+	dx, dy := f.Direction(f.Grid.WorldToGrid(x, y))
+	if dx != 0 && dy != 0 {
+		return float64(dx) / math.Sqrt2, float64(dy) / math.Sqrt2
+	}
+	return float64(dx), float64(dy)
+}
@@ -0,0 +1,64 @@
+package paths
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func TestFlowFieldUpdateMatchesBuild(t *testing.T) {
+
+	rng := rand.New(rand.NewSource(8))
+
+	for i := 0; i < 30; i++ {
+
+		m := randomGrid(rng, 25, 20, 0.2, true)
+		options := &PathOptions{Movement: Movement(i % 2)}
+		goals := []*Cell{randomCell(rng, m), randomCell(rng, m)}
+
+		build := func() updater {
+			f := NewFlowField(m, options)
+			for j, goal := range goals {
+				f.AddGoal(goal, float64(j*3))
+			}
+			f.Build()
+			return f
+		}
+
+		// Walling off a goal and opening it again has to seed it again, and the Cells around it have to flow back in.
+		goal := goals[0]
+		edits := []gridEdit{
+			{Rect{X: goal.X, Y: goal.Y, W: 1, H: 1}, false, 1},
+			{Rect{X: goal.X - 1, Y: goal.Y - 1, W: 3, H: 3}, true, 4},
+			{Rect{X: goal.X, Y: goal.Y, W: 1, H: 1}, true, 1},
+			{Rect{X: 0, Y: 0, W: 25, H: 1}, false, 1},
+		}
+		for j := 0; j < 20; j++ {
+			edits = append(edits, randomEdit(rng, m, 3))
+		}
+
+		testUpdates(m, i%2 == 0, edits, build, func(got, want updater) {
+
+			f, fresh := got.(*FlowField), want.(*FlowField)
+
+			for _, cell := range m.AllCells() {
+
+				cost, wantCost := f.Cost(cell.X, cell.Y), fresh.Cost(cell.X, cell.Y)
+				if cost != wantCost && math.Abs(cost-wantCost) > 1e-9 {
+					t.Fatalf("updated FlowField costs %f at %s, but a rebuilt one costs %f", cost, cell, wantCost)
+				}
+
+				// Following the field has to lead the way the cost says it does.
+				if next := f.Next(cell); next != nil {
+					if through := m.EdgeCost(cell, next, options) + f.Cost(next.X, next.Y); math.Abs(through-cost) > 1e-9 {
+						t.Fatalf("updated FlowField leads from %s (cost %f) to %s, costing %f", cell, cost, next, through)
+					}
+				}
+
+			}
+
+		})
+
+	}
+
+}
@@ -0,0 +1,181 @@
+package paths
+
+import "math"
+
+// Anchor indicates which point of each Cell a PathFollower moves between.
+type Anchor int
+
+const (
+	// AnchorCenter moves between the centers of Cells.
+	AnchorCenter Anchor = iota
+	// AnchorCorner moves between the top-left corners of Cells (the world positions returned by Grid.GridToWorld()).
+	AnchorCorner
+)
+
+// A PathFollower moves along a Path through the world at a steady speed, so that agents don't each have to work out how to
+// head towards the next Cell and when they've reached it. Each frame, call Update() with the time that's passed, and move the
+// agent to the Position() it returns. The PathFollower advances the Path as it goes, so Path.Current() is always the last
+// Cell reached.
+type PathFollower struct {
+	Grid *Grid
+	Path *Path
+
+	// Speed is how far the PathFollower moves in the world per unit of time (i.e. per second, if Update() is given the time in
+	// seconds).
+	Speed float64
+	// ArrivalRadius is how close the PathFollower has to get to a Cell for it to count as reached. The PathFollower then heads
+	// for the next Cell right away, so a larger radius rounds off corners. The last Cell of the Path is reached in the same
+	// way, so the PathFollower stops once it's within the radius of it.
+	ArrivalRadius float64
+	// Anchor controls which point of each Cell the PathFollower moves between.
+	Anchor Anchor
+
+	// OnWaypoint, if set, is called whenever the PathFollower reaches a Cell of the Path, with the Cell and its index.
+	OnWaypoint func(cell *Cell, index int)
+	// OnComplete, if set, is called once the PathFollower reaches the end of the Path.
+	OnComplete func()
+
+	position Vector
+	placed   bool
+	heading  float64
+	done     bool
+}
+
+// NewPathFollower returns a new PathFollower that follows the Path provided across the Grid, starting from the Path's current
+// Cell, at the speed given. arrivalRadius is how close the PathFollower has to get to each Cell for it to count as reached.
+func NewPathFollower(grid *Grid, path *Path, speed, arrivalRadius float64) *PathFollower {
+	f := &PathFollower{
+		Grid:          grid,
+		Speed:         speed,
+		ArrivalRadius: arrivalRadius,
+	}
+	f.SetPath(path)
+	return f
+}
+
+// SetPath sets the Path the PathFollower follows, moving it to the Path's current Cell.
+func (f *PathFollower) SetPath(path *Path) {
+	f.Path = path
+	f.placed = false
+	f.done = false
+}
+
+// place moves the PathFollower to the current Cell of its Path, facing the next one, if it hasn't been placed yet. This waits
+// until the PathFollower is used, so that Anchor can be changed after setting the Path.
+func (f *PathFollower) place() {
+
+	if f.placed || f.Path == nil || len(f.Path.Cells) == 0 {
+		return
+	}
+
+	f.placed = true
+	f.position = f.point(f.Path.Current())
+	if next := f.Path.Next(); next != nil {
+		to := f.point(next)
+		f.heading = math.Atan2(to.Y-f.position.Y, to.X-f.position.X)
+	}
+
+}
+
+// Update moves the PathFollower along its Path for the time provided (dt, or delta time), and returns its new position. If it
+// passes several Cells in one Update() (say, at high speeds), each of them is reached in turn.
+func (f *PathFollower) Update(dt float64) Vector {
+
+	f.place()
+
+	if f.done {
+		return f.position
+	}
+
+	if f.Path == nil || f.Path.Next() == nil {
+		f.complete()
+		return f.position
+	}
+
+	distance := f.Speed * dt
+
+	for {
+
+		next := f.Path.Next()
+		if next == nil {
+			f.complete()
+			break
+		}
+
+		target := f.point(next)
+		dx, dy := target.X-f.position.X, target.Y-f.position.Y
+		remaining := math.Hypot(dx, dy)
+
+		if remaining > f.ArrivalRadius {
+
+			if distance <= 0 {
+				break
+			}
+
+			step := math.Min(distance, remaining)
+			f.position.X += dx / remaining * step
+			f.position.Y += dy / remaining * step
+			f.heading = math.Atan2(dy, dx)
+			distance -= step
+			remaining -= step
+
+			if remaining > f.ArrivalRadius {
+				break
+			}
+
+		}
+
+		f.Path.Advance()
+		if f.OnWaypoint != nil {
+			f.OnWaypoint(next, f.Path.CurrentIndex)
+		}
+
+	}
+
+	return f.position
+
+}
+
+// complete marks the PathFollower as having reached the end of its Path.
+func (f *PathFollower) complete() {
+	if !f.done {
+		f.done = true
+		if f.OnComplete != nil {
+			f.OnComplete()
+		}
+	}
+}
+
+// point returns the world position of the Cell provided, according to the PathFollower's Anchor.
+func (f *PathFollower) point(cell *Cell) Vector {
+	if f.Anchor == AnchorCorner {
+		x, y := f.Grid.GridToWorld(cell.X, cell.Y)
+		return Vector{x, y}
+	}
+	return f.Grid.cellCenter(cell)
+}
+
+// Position returns the PathFollower's current position in the world.
+func (f *PathFollower) Position() Vector {
+	f.place()
+	return f.position
+}
+
+// SetPosition moves the PathFollower to the world position provided (for example, if the agent was pushed off course). It
+// carries on towards the next Cell of the Path from there.
+func (f *PathFollower) SetPosition(x, y float64) {
+	f.position = Vector{x, y}
+	f.placed = true
+}
+
+// Heading returns the angle the PathFollower is moving in, in radians (0 being to the right, and increasing towards +Y).
+// When it isn't moving, it keeps the heading it last moved in.
+func (f *PathFollower) Heading() float64 {
+	f.place()
+	return f.heading
+}
+
+// Done returns whether the PathFollower has reached the end of its Path.
+func (f *PathFollower) Done() bool {
+	return f.done
+}
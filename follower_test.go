@@ -0,0 +1,136 @@
+package paths
+
+import (
+	"math"
+	"testing"
+)
+
+// rowPath returns a Grid with a Path along its top row, from the first Cell to the last.
+func rowPath(length int) (*Grid, *Path) {
+	m := NewGrid(length, 3, 16, 16)
+	path := &Path{}
+	for x := 0; x < length; x++ {
+		path.Cells = append(path.Cells, m.Get(x, 0))
+	}
+	return m, path
+}
+
+func TestPathFollowerArrivalRadius(t *testing.T) {
+
+	// Moving 4 units at a time from the center of the first Cell (at 8) towards the center of the last (at 72), the PathFollower
+	// stops at the first position within the radius of it.
+	for _, test := range []struct {
+		radius float64
+		want   Vector
+	}{
+		{0, Vector{72, 8}},
+		{6, Vector{68, 8}},
+	} {
+
+		m, path := rowPath(5)
+		radius, want := test.radius, test.want
+		f := NewPathFollower(m, path, 16, radius)
+		end := m.cellCenter(path.Cells[4])
+
+		for i := 0; i < 100 && !f.Done(); i++ {
+			if p := f.Position(); math.Hypot(end.X-p.X, end.Y-p.Y) <= radius {
+				t.Fatalf("radius %f: PathFollower at %v is within the radius of the end, but isn't done", radius, p)
+			}
+			f.Update(0.25)
+		}
+
+		if !f.Done() {
+			t.Fatalf("radius %f: PathFollower never reached the end of the Path", radius)
+		}
+		if p := f.Position(); p != want {
+			t.Fatalf("radius %f: PathFollower stopped at %v, not %v", radius, p, want)
+		}
+		if path.Current() != path.Cells[4] {
+			t.Fatalf("radius %f: PathFollower is done, but its Path is at %s", radius, path.Current())
+		}
+
+		// Once done, it stays put.
+		if p := f.Update(1); p != want {
+			t.Fatalf("radius %f: PathFollower moved to %v after it was done", radius, p)
+		}
+
+	}
+
+}
+
+func TestPathFollowerCallbacks(t *testing.T) {
+
+	m, path := rowPath(5)
+	f := NewPathFollower(m, path, 16, 0)
+
+	reached := []int{}
+	completed := 0
+	f.OnWaypoint = func(cell *Cell, index int) {
+		if cell != path.Cells[index] {
+			t.Fatalf("OnWaypoint was called with %s, but Cell %d of the Path is %s", cell, index, path.Cells[index])
+		}
+		reached = append(reached, index)
+	}
+	f.OnComplete = func() {
+		if len(reached) != 4 {
+			t.Fatalf("OnComplete was called after reaching %d Cells, not 4", len(reached))
+		}
+		completed++
+	}
+
+	// Each Update() moves one Cell along, reaching it exactly.
+	for i := 1; i <= 4; i++ {
+		f.Update(1)
+		if len(reached) != i || reached[i-1] != i {
+			t.Fatalf("after %d updates, OnWaypoint was called for Cells %v", i, reached)
+		}
+	}
+
+	for i := 0; i < 3; i++ {
+		f.Update(1)
+	}
+	if completed != 1 {
+		t.Fatalf("OnComplete was called %d times, not once", completed)
+	}
+
+	// Setting a new Path starts over.
+	_, other := rowPath(3)
+	f.SetPath(other)
+	if f.Done() {
+		t.Fatalf("PathFollower is still done after being given a new Path")
+	}
+
+}
+
+func TestPathFollowerHighSpeed(t *testing.T) {
+
+	// The Path turns a corner, heading right and then down: (8, 8), (24, 8), (40, 8), (40, 24), and (40, 40).
+	m := NewGrid(3, 3, 16, 16)
+	path := &Path{Cells: []*Cell{m.Get(0, 0), m.Get(1, 0), m.Get(2, 0), m.Get(2, 1), m.Get(2, 2)}}
+	f := NewPathFollower(m, path, 40, 0)
+
+	reached := []int{}
+	completed := 0
+	f.OnWaypoint = func(cell *Cell, index int) { reached = append(reached, index) }
+	f.OnComplete = func() { completed++ }
+
+	// Moving 40 units at once passes two Cells and turns the corner, rather than overshooting it.
+	if p := f.Update(1); p != (Vector{40, 16}) {
+		t.Fatalf("PathFollower moved to %v, not around the corner to %v", p, Vector{40, 16})
+	}
+	if len(reached) != 2 || reached[0] != 1 || reached[1] != 2 {
+		t.Fatalf("PathFollower reached Cells %v, not [1 2]", reached)
+	}
+	if math.Abs(f.Heading()-math.Pi/2) > 1e-9 {
+		t.Fatalf("PathFollower heads at %f radians after turning the corner, not %f", f.Heading(), math.Pi/2)
+	}
+
+	// Moving far past the end stops at the end, after reaching every Cell on the way.
+	if p := f.Update(100); p != (Vector{40, 40}) {
+		t.Fatalf("PathFollower moved to %v, past the end of the Path at %v", p, Vector{40, 40})
+	}
+	if len(reached) != 4 || reached[2] != 3 || reached[3] != 4 || completed != 1 || !f.Done() {
+		t.Fatalf("PathFollower reached Cells %v and completed %d times", reached, completed)
+	}
+
+}
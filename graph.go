@@ -0,0 +1,239 @@
+package paths
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// A Graph is anything Paths can be found through: a set of nodes, numbered from 0 up to Nodes() - 1, connected by Edges that
+// each have a cost. Grids, CompactGrids, and WaypointGraphs are all Graphs, but so could be a navmesh or your own level
+// representation; SearchGraph() searches any of them.
+type Graph interface {
+	// Nodes returns the number of nodes in the Graph.
+	Nodes() int
+	// AppendEdges appends the Edges leading out of the node provided to edges, and returns the result.
+	AppendEdges(edges []Edge, node int) []Edge
+	// Estimate estimates the cost of moving from one node to another, guiding the search towards the destination as a
+	// Heuristic does. As long as it never overestimates, the Path found is the cheapest one. Returning 0 checks nodes evenly in
+	// all directions (a Dijkstra search).
+	Estimate(from, to int) float64
+}
+
+// An Edge is a connection from one node of a Graph to another, along with the cost of moving along it.
+type Edge struct {
+	To   int
+	Cost float64
+}
+
+// A GraphResult is the outcome of searching a Graph, along with some statistics about it. For Grids and CompactGrids, its Cost
+// includes the Cost of the starting Cell, as with a PathResult; for other Graphs, it's just the cost of the Edges moved along.
+type GraphResult struct {
+	// Nodes are the nodes along the Path found, from the start to the destination, or nil if Err is set.
+	Nodes []int
+	SearchStats
+}
+
+var graphSearches sync.Pool
+
+// SearchGraph searches the Graph provided for the cheapest Path from the start node to the destination node. options controls
+// the search as it does for Grids, apart from the options that only make sense for Grids (Movement, DiagonalCost,
+// CornerCutting, Heuristic, PartialScore, and Algorithm), which are up to the Graph; for example, Grid.Graph() takes its own
+// PathOptions. When AllowPartial is set, the Graph's Estimate picks the node closest to the destination, apart from the Graph
+// returned by Grid.Graph(), which uses its own PathOptions' PartialScore (or Heuristic, or Euclidean distance) instead.
+func SearchGraph(graph Graph, start, dest int, options *PathOptions) *GraphResult {
+
+	began := time.Now()
+
+	if start < 0 || dest < 0 || start >= graph.Nodes() || dest >= graph.Nodes() {
+		return &GraphResult{SearchStats: SearchStats{Err: ErrOutOfBounds}}
+	}
+
+	s, ok := graphSearches.Get().(*indexSearch)
+	if !ok {
+		s = &indexSearch{}
+	}
+	result := s.search(graph, start, dest, options)
+	graphSearches.Put(s)
+
+	result.Duration = time.Since(began)
+	return result
+
+}
+
+// Nodes returns the number of Cells in the Grid, so that a Grid can be used as a Graph. Each Cell's node is its Index().
+func (m *Grid) Nodes() int {
+	return m.Width() * m.Height()
+}
+
+// AppendEdges appends the Edges leading from the Cell at the index provided to its walkable neighbors, moving orthogonally.
+// This lets a Grid be used as a Graph; for other kinds of movement, use Graph() instead.
+func (m *Grid) AppendEdges(edges []Edge, node int) []Edge {
+
+	x, y := m.Position(node)
+
+	for _, d := range [4][2]int{{-1, 0}, {1, 0}, {0, -1}, {0, 1}} {
+		if c := m.Get(x+d[0], y+d[1]); c != nil && c.Walkable {
+			if cost := m.stepCost(m.GetIndex(node), c, nil); !math.IsInf(cost, 1) {
+				edges = append(edges, Edge{m.Index(c.X, c.Y), cost})
+			}
+		}
+	}
+
+	return edges
+
+}
+
+// Estimate estimates the cost of moving between the Cells at the indices provided using the Manhattan Heuristic, so that a Grid
+// can be used as a Graph.
+func (m *Grid) Estimate(from, to int) float64 {
+	fx, fy := m.Position(from)
+	tx, ty := m.Position(to)
+	return float64(abs(tx-fx) + abs(ty-fy))
+}
+
+func (m *Grid) startCost(node int) float64 {
+	return m.GetIndex(node).Cost
+}
+
+// Graph returns the Grid as a Graph whose Edges follow the PathOptions provided, as they would when searching the Grid itself.
+// The Graph's Estimate uses the options' Heuristic (or 0, if there isn't one). The Graph holds on to the memory it uses, so it
+// isn't safe to search from multiple goroutines at once; call Graph() once for each goroutine.
+func (m *Grid) Graph(options *PathOptions) Graph {
+	return &gridGraph{grid: m, options: options, heuristic: options.heuristic()}
+}
+
+// PathFromNodes returns a Path through the Cells at the indices provided, such as the Nodes of a GraphResult from searching the
+// Grid.
+func (m *Grid) PathFromNodes(nodes []int) *Path {
+	path := &Path{Cells: make([]*Cell, len(nodes))}
+	for i, n := range nodes {
+		path.Cells[i] = m.GetIndex(n)
+	}
+	return path
+}
+
+// gridGraph is a Grid searched using a particular set of PathOptions. Pathfinders search Grids through one, too, in which case
+// it can also jump (see jps.go) and keep the search within bounds.
+type gridGraph struct {
+	grid      *Grid
+	options   *PathOptions
+	heuristic Heuristic
+	neighbors []neighbor
+
+	// dest is the destination of the current search, which jumps stop at.
+	dest       *Cell
+	jumping    bool
+	jumpTable  *jumpTable
+	directions []int
+	// octile is the Heuristic used when jumping without one of the options' own.
+	octile octileCost
+	// bounds, if set, is a rectangle of Cells that the search can't leave.
+	bounds *bounds
+}
+
+func (g *gridGraph) Nodes() int {
+	return g.grid.Nodes()
+}
+
+func (g *gridGraph) AppendEdges(edges []Edge, node int) []Edge {
+	g.neighbors = g.grid.appendNeighbors(g.neighbors[:0], g.grid.GetIndex(node), g.options)
+	for _, n := range g.neighbors {
+		if g.bounds == nil || g.bounds.contains(n.Cell) {
+			edges = append(edges, Edge{g.grid.Index(n.Cell.X, n.Cell.Y), n.Cost})
+		}
+	}
+	return edges
+}
+
+func (g *gridGraph) appendEdgesFrom(edges []Edge, node, parent int) []Edge {
+	if !g.jumping {
+		return g.AppendEdges(edges, node)
+	}
+	var from *Cell
+	if parent >= 0 {
+		from = g.grid.GetIndex(parent)
+	}
+	return g.appendJumpSuccessors(edges, g.grid.GetIndex(node), from)
+}
+
+func (g *gridGraph) Estimate(from, to int) float64 {
+	if g.heuristic != nil {
+		return g.heuristic.Estimate(g.grid.GetIndex(from), g.grid.GetIndex(to))
+	}
+	return 0
+}
+
+func (g *gridGraph) startCost(node int) float64 {
+	return g.grid.GetIndex(node).Cost
+}
+
+func (g *gridGraph) partialScore(node, dest int) float64 {
+	return g.options.partialScore(g.grid.GetIndex(node), g.grid.GetIndex(dest))
+}
+
+// A WaypointGraph is a simple Graph of waypoints placed freely in the world and connected by hand, like a patrol network or
+// the centers of navmesh polygons. Its Estimate is the straight-line distance between waypoints, so connection costs should be
+// at least the distance they cover.
+type WaypointGraph struct {
+	Waypoints []Waypoint
+}
+
+// A Waypoint is a node in a WaypointGraph.
+type Waypoint struct {
+	X, Y  float64
+	Edges []Edge
+}
+
+// NewWaypointGraph returns a new, empty WaypointGraph.
+func NewWaypointGraph() *WaypointGraph {
+	return &WaypointGraph{}
+}
+
+// Add adds a waypoint at the world position provided, returning its node.
+func (g *WaypointGraph) Add(x, y float64) int {
+	g.Waypoints = append(g.Waypoints, Waypoint{X: x, Y: y})
+	return len(g.Waypoints) - 1
+}
+
+// Connect connects two waypoints in both directions, costing the distance between them.
+func (g *WaypointGraph) Connect(a, b int) {
+	cost := g.distance(a, b)
+	g.ConnectOneWay(a, b, cost)
+	g.ConnectOneWay(b, a, cost)
+}
+
+// ConnectOneWay connects one waypoint to another (but not back) with the cost provided.
+func (g *WaypointGraph) ConnectOneWay(from, to int, cost float64) {
+	g.Waypoints[from].Edges = append(g.Waypoints[from].Edges, Edge{to, cost})
+}
+
+// Nearest returns the waypoint closest to the world position provided, or -1 if there are no waypoints.
+func (g *WaypointGraph) Nearest(x, y float64) int {
+	nearest, best := -1, math.Inf(1)
+	for i, w := range g.Waypoints {
+		if d := math.Hypot(w.X-x, w.Y-y); d < best {
+			nearest, best = i, d
+		}
+	}
+	return nearest
+}
+
+// Nodes returns the number of waypoints in the WaypointGraph.
+func (g *WaypointGraph) Nodes() int {
+	return len(g.Waypoints)
+}
+
+// AppendEdges appends the connections leading out of the waypoint provided.
+func (g *WaypointGraph) AppendEdges(edges []Edge, node int) []Edge {
+	return append(edges, g.Waypoints[node].Edges...)
+}
+
+// Estimate returns the straight-line distance between two waypoints.
+func (g *WaypointGraph) Estimate(from, to int) float64 {
+	return g.distance(from, to)
+}
+
+func (g *WaypointGraph) distance(a, b int) float64 {
+	return math.Hypot(g.Waypoints[b].X-g.Waypoints[a].X, g.Waypoints[b].Y-g.Waypoints[a].Y)
+}
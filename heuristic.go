@@ -0,0 +1,71 @@
+package paths
+
+import "math"
+
+// A Heuristic estimates the cost of moving from one Cell to another. Pathfinding uses the estimate to guide the search towards
+// the destination, so that fewer Cells have to be checked. As long as a Heuristic never overestimates the true cost of moving
+// between two Cells, the Path returned will still be the cheapest one. The built-in Heuristics assume that no Cell has a Cost
+// lower than 1 (the default).
+type Heuristic interface {
+	Estimate(from, to *Cell) float64
+}
+
+// HeuristicFunc is a function that satisfies the Heuristic interface, allowing you to easily supply your own heuristic.
+type HeuristicFunc func(from, to *Cell) float64
+
+// Estimate returns the result of calling the HeuristicFunc with the two Cells provided.
+func (f HeuristicFunc) Estimate(from, to *Cell) float64 {
+	return f(from, to)
+}
+
+var (
+	// Manhattan is a Heuristic that sums the horizontal and vertical distance between two Cells. It's ideal for Paths that
+	// don't allow diagonal movement.
+	Manhattan Heuristic = HeuristicFunc(manhattan)
+
+	// Chebyshev is a Heuristic that returns the larger of the horizontal and vertical distance between two Cells. It's suitable for
+	// Paths where diagonal movement costs the same as orthogonal movement.
+	Chebyshev Heuristic = HeuristicFunc(chebyshev)
+
+	// Octile is a Heuristic that measures distance when moving diagonally costs slightly more than moving orthogonally, using
+	// DefaultDiagonalCost. It's ideal for Paths that allow diagonal movement.
+	Octile Heuristic = HeuristicFunc(octile)
+
+	// Euclidean is a Heuristic that returns the straight-line distance between two Cells.
+	Euclidean Heuristic = HeuristicFunc(euclidean)
+)
+
+func cellDeltas(from, to *Cell) (float64, float64) {
+	return math.Abs(float64(to.X - from.X)), math.Abs(float64(to.Y - from.Y))
+}
+
+func manhattan(from, to *Cell) float64 {
+	dx, dy := cellDeltas(from, to)
+	return dx + dy
+}
+
+func chebyshev(from, to *Cell) float64 {
+	dx, dy := cellDeltas(from, to)
+	return math.Max(dx, dy)
+}
+
+func octile(from, to *Cell) float64 {
+	dx, dy := cellDeltas(from, to)
+	return math.Max(dx, dy) + DefaultDiagonalCost*math.Min(dx, dy)
+}
+
+// An octileCost is an octile Heuristic that's exact for moving onto Cells that all have the same Cost, with the DiagonalCost
+// given.
+type octileCost struct {
+	cost, diagonalCost float64
+}
+
+func (o *octileCost) Estimate(from, to *Cell) float64 {
+	dx, dy := cellDeltas(from, to)
+	return o.cost*math.Max(dx, dy) + o.diagonalCost*math.Min(dx, dy)
+}
+
+func euclidean(from, to *Cell) float64 {
+	dx, dy := cellDeltas(from, to)
+	return math.Sqrt(dx*dx + dy*dy)
+}
@@ -0,0 +1,269 @@
+package paths
+
+import (
+	"math"
+	"time"
+)
+
+// HexOrientation controls which way the hexagons of a HexGrid point.
+type HexOrientation int
+
+const (
+	// PointyTop hexagons have a corner at the top. Rows are lined up horizontally, with every odd row shoved half a hexagon to
+	// the right.
+	PointyTop HexOrientation = iota
+	// FlatTop hexagons have a flat edge at the top. Columns are lined up vertically, with every odd column shoved half a hexagon
+	// down.
+	FlatTop
+)
+
+// A Hex is a position on a HexGrid in axial coordinates. Unlike the offset coordinates Cells use (where every other row or
+// column is shoved over), axial coordinates run straight along two of the hexagons' axes, which makes math with them simple.
+// The third (cube) coordinate, S, is implied by the other two.
+type Hex struct {
+	Q, R int
+}
+
+// hexDirections are the axial offsets of the six neighbors of a Hex.
+var hexDirections = [6]Hex{{1, 0}, {1, -1}, {0, -1}, {-1, 0}, {-1, 1}, {0, 1}}
+
+// S returns the third cube coordinate of the Hex, such that Q + R + S = 0.
+func (h Hex) S() int {
+	return -h.Q - h.R
+}
+
+// Add returns the sum of the two Hexes.
+func (h Hex) Add(other Hex) Hex {
+	return Hex{h.Q + other.Q, h.R + other.R}
+}
+
+// Neighbor returns the neighboring Hex in the direction provided, from 0 to 5, going counter-clockwise from the right (for
+// PointyTop HexGrids) or the lower-right (for FlatTop ones).
+func (h Hex) Neighbor(direction int) Hex {
+	return h.Add(hexDirections[((direction%6)+6)%6])
+}
+
+// Distance returns the number of steps between two Hexes.
+func (h Hex) Distance(other Hex) int {
+	return max(abs(h.Q-other.Q), max(abs(h.R-other.R), abs(h.S()-other.S())))
+}
+
+// hexRound rounds fractional axial coordinates to the nearest Hex.
+func hexRound(q, r float64) Hex {
+
+	s := -q - r
+	rq, rr, rs := math.Round(q), math.Round(r), math.Round(s)
+	dq, dr, ds := math.Abs(rq-q), math.Abs(rr-r), math.Abs(rs-s)
+
+	// Rounding each coordinate separately can break Q + R + S = 0, so the one that was rounded the most gets recalculated.
+	if dq > dr && dq > ds {
+		rq = -rr - rs
+	} else if dr > ds {
+		rr = -rq - rs
+	}
+
+	return Hex{int(rq), int(rr)}
+
+}
+
+// A HexGrid is a map of hexagonal Cells. The Cells are stored in Grid in offset coordinates (so each row or column is stored as
+// it's laid out, with every other one shoved over, as described by the HexOrientation), which means the Grid's functions can be
+// used to look up and change Cells. Search for Paths using the HexGrid's functions rather than the Grid's, though, since the
+// Grid would treat the Cells as squares.
+type HexGrid struct {
+	Grid        *Grid
+	Orientation HexOrientation
+	// HexWidth and HexHeight are the size of a hexagon in the world, used to translate between hexagons and world positions.
+	HexWidth, HexHeight int
+}
+
+// NewHexGrid returns a new HexGrid of (gridWidth x gridHeight) size, with hexagons hexWidth x hexHeight in size in the world and
+// pointing in the orientation provided. By default, all Cells are walkable, have a cost of 1, and a blank rune of ' '.
+func NewHexGrid(gridWidth, gridHeight, hexWidth, hexHeight int, orientation HexOrientation) *HexGrid {
+	return &HexGrid{
+		Grid:        NewGrid(gridWidth, gridHeight, hexWidth, hexHeight),
+		Orientation: orientation,
+		HexWidth:    hexWidth,
+		HexHeight:   hexHeight,
+	}
+}
+
+// NewHexGridFromStringArrays creates a HexGrid from a 1D array of strings, as NewGridFromStringArrays() does for a Grid. Each
+// string becomes a row of Cells in offset coordinates, each with one rune as its character.
+func NewHexGridFromStringArrays(arrays []string, hexWidth, hexHeight int, orientation HexOrientation) *HexGrid {
+	return &HexGrid{
+		Grid:        NewGridFromStringArrays(arrays, hexWidth, hexHeight),
+		Orientation: orientation,
+		HexWidth:    hexWidth,
+		HexHeight:   hexHeight,
+	}
+}
+
+// Get returns a pointer to the Cell at the offset position provided, or nil if it's outside of the HexGrid.
+func (h *HexGrid) Get(x, y int) *Cell {
+	return h.Grid.Get(x, y)
+}
+
+// OffsetToHex converts an offset position (as used by Cells) to a Hex.
+func (h *HexGrid) OffsetToHex(x, y int) Hex {
+	if h.Orientation == FlatTop {
+		return Hex{x, y - (x-(x&1))/2}
+	}
+	return Hex{x - (y-(y&1))/2, y}
+}
+
+// HexToOffset converts a Hex to an offset position (as used by Cells).
+func (h *HexGrid) HexToOffset(hex Hex) (int, int) {
+	if h.Orientation == FlatTop {
+		return hex.Q, hex.R + (hex.Q-(hex.Q&1))/2
+	}
+	return hex.Q + (hex.R-(hex.R&1))/2, hex.R
+}
+
+// CellHex returns the Hex of the Cell provided.
+func (h *HexGrid) CellHex(cell *Cell) Hex {
+	return h.OffsetToHex(cell.X, cell.Y)
+}
+
+// GetHex returns the Cell at the Hex provided, or nil if it's outside of the HexGrid.
+func (h *HexGrid) GetHex(hex Hex) *Cell {
+	return h.Grid.Get(h.HexToOffset(hex))
+}
+
+// Neighbors returns the (up to six) Cells next to the Cell provided.
+func (h *HexGrid) Neighbors(cell *Cell) []*Cell {
+	neighbors := make([]*Cell, 0, 6)
+	hex := h.CellHex(cell)
+	for d := range hexDirections {
+		if n := h.GetHex(hex.Neighbor(d)); n != nil {
+			neighbors = append(neighbors, n)
+		}
+	}
+	return neighbors
+}
+
+// Distance returns the number of steps between two Cells.
+func (h *HexGrid) Distance(a, b *Cell) int {
+	return h.CellHex(a).Distance(h.CellHex(b))
+}
+
+// HexToWorld converts from an offset position to the world position of the center of its hexagon. The hexagon at 0, 0 is
+// centered on the world origin.
+func (h *HexGrid) HexToWorld(x, y int) (float64, float64) {
+	hex := h.OffsetToHex(x, y)
+	w, ht := float64(h.HexWidth), float64(h.HexHeight)
+	if h.Orientation == FlatTop {
+		return w * 0.75 * float64(hex.Q), ht * (float64(hex.R) + float64(hex.Q)/2)
+	}
+	return w * (float64(hex.Q) + float64(hex.R)/2), ht * 0.75 * float64(hex.R)
+}
+
+// WorldToHex converts from a world position to the offset position of the hexagon it lies in.
+func (h *HexGrid) WorldToHex(x, y float64) (int, int) {
+	w, ht := float64(h.HexWidth), float64(h.HexHeight)
+	if h.Orientation == FlatTop {
+		q := x / (w * 0.75)
+		return h.HexToOffset(hexRound(q, y/ht-q/2))
+	}
+	r := y / (ht * 0.75)
+	return h.HexToOffset(hexRound(x/w-r/2, r))
+}
+
+// GetPathFromCells returns a Path from the starting Cell to the destination Cell, moving between neighboring hexagons. options
+// controls the search as it does for Grid.GetPathFromCells(), other than Movement, DiagonalCost, CornerCutting, and Algorithm,
+// which don't apply. If options has no Heuristic, the number of steps between hexagons is used.
+func (h *HexGrid) GetPathFromCells(start, dest *Cell, options *PathOptions) *Path {
+	return pathFromResult(h.SearchFromCells(start, dest, options))
+}
+
+// GetPath returns a Path from the starting world position to the ending world position, as with GetPathFromCells().
+func (h *HexGrid) GetPath(startX, startY, endX, endY float64, options *PathOptions) *Path {
+	return pathFromResult(h.Search(startX, startY, endX, endY, options))
+}
+
+// Search searches for a Path from the starting world position to the ending world position, as with GetPath(), and returns the
+// result.
+func (h *HexGrid) Search(startX, startY, endX, endY float64, options *PathOptions) *PathResult {
+	return h.SearchFromCells(h.Grid.Get(h.WorldToHex(startX, startY)), h.Grid.Get(h.WorldToHex(endX, endY)), options)
+}
+
+// SearchFromCells searches for a Path from the starting Cell to the destination Cell, as with GetPathFromCells(), and returns
+// the result.
+func (h *HexGrid) SearchFromCells(start, dest *Cell, options *PathOptions) *PathResult {
+
+	began := time.Now()
+
+	if err := checkEnds(start, dest); err != nil && (err != ErrDestBlocked || !options.allowPartial()) {
+		return &PathResult{SearchStats: SearchStats{Err: err}}
+	}
+
+	found := SearchGraph(&hexGraph{h, options}, h.Grid.Index(start.X, start.Y), h.Grid.Index(dest.X, dest.Y), options)
+
+	result := &PathResult{SearchStats: found.SearchStats}
+	if found.Nodes != nil {
+		result.Path = h.Grid.PathFromNodes(found.Nodes)
+	}
+	result.Duration = time.Since(began)
+	return result
+
+}
+
+// Nodes returns the number of Cells in the HexGrid, so that a HexGrid can be used as a Graph. Each Cell's node is its
+// Grid.Index().
+func (h *HexGrid) Nodes() int {
+	return h.Grid.Nodes()
+}
+
+// AppendEdges appends the Edges leading from the Cell at the index provided to its walkable neighbors, so that a HexGrid can be
+// used as a Graph. Edge costs set on the Grid (and its EdgeCostFunc) apply to steps between hexagons as they do between squares.
+func (h *HexGrid) AppendEdges(edges []Edge, node int) []Edge {
+	return h.appendEdges(edges, node, nil)
+}
+
+// appendEdges appends the Edges leading from the Cell at the index provided to its neighbors, following the PassableFunc and
+// CostFunc of the PathOptions provided.
+func (h *HexGrid) appendEdges(edges []Edge, node int, options *PathOptions) []Edge {
+	cell := h.Grid.GetIndex(node)
+	hex := h.CellHex(cell)
+	for _, d := range hexDirections {
+		if c := h.GetHex(hex.Add(d)); c != nil && c.Walkable && options.passable(cell, c) {
+			if cost := options.stepCost(cell, c, h.Grid.edgeCost(cell, c, c.Cost)); !math.IsInf(cost, 1) {
+				edges = append(edges, Edge{h.Grid.Index(c.X, c.Y), cost})
+			}
+		}
+	}
+	return edges
+}
+
+// Estimate returns the number of steps between the Cells at the indices provided, so that a HexGrid can be used as a Graph.
+func (h *HexGrid) Estimate(from, to int) float64 {
+	return float64(h.OffsetToHex(h.Grid.Position(from)).Distance(h.OffsetToHex(h.Grid.Position(to))))
+}
+
+func (h *HexGrid) startCost(node int) float64 {
+	return h.Grid.startCost(node)
+}
+
+// hexGraph is a HexGrid searched using a particular set of PathOptions.
+type hexGraph struct {
+	*HexGrid
+	options *PathOptions
+}
+
+func (g *hexGraph) AppendEdges(edges []Edge, node int) []Edge {
+	return g.appendEdges(edges, node, g.options)
+}
+
+func (g *hexGraph) Estimate(from, to int) float64 {
+	if heuristic := g.options.heuristic(); heuristic != nil {
+		return heuristic.Estimate(g.Grid.GetIndex(from), g.Grid.GetIndex(to))
+	}
+	return g.HexGrid.Estimate(from, to)
+}
+
+func (g *hexGraph) partialScore(node, dest int) float64 {
+	if g.options.PartialScore != nil {
+		return g.options.PartialScore(g.Grid.GetIndex(node), g.Grid.GetIndex(dest))
+	}
+	return g.HexGrid.Estimate(node, dest)
+}
@@ -0,0 +1,103 @@
+package paths
+
+import (
+	"sort"
+	"testing"
+)
+
+func TestHexOffsetRoundTrip(t *testing.T) {
+
+	for _, orientation := range []HexOrientation{PointyTop, FlatTop} {
+
+		h := NewHexGrid(10, 10, 16, 14, orientation)
+
+		// Positions outside of the HexGrid, including negative ones, have to convert just as well.
+		for y := -5; y < 15; y++ {
+			for x := -5; x < 15; x++ {
+				if ox, oy := h.HexToOffset(h.OffsetToHex(x, y)); ox != x || oy != y {
+					t.Fatalf("orientation %d: offset position %d, %d converts to %v and back to %d, %d", orientation, x, y, h.OffsetToHex(x, y), ox, oy)
+				}
+				wx, wy := h.HexToWorld(x, y)
+				if ox, oy := h.WorldToHex(wx, wy); ox != x || oy != y {
+					t.Fatalf("orientation %d: offset position %d, %d is centered at %f, %f, which lies in %d, %d", orientation, x, y, wx, wy, ox, oy)
+				}
+			}
+		}
+
+		for r := -5; r < 15; r++ {
+			for q := -5; q < 15; q++ {
+				hex := Hex{q, r}
+				if back := h.OffsetToHex(h.HexToOffset(hex)); back != hex {
+					t.Fatalf("orientation %d: %v converts to an offset position and back to %v", orientation, hex, back)
+				}
+			}
+		}
+
+	}
+
+}
+
+func TestHexNeighbors(t *testing.T) {
+
+	type position struct{ x, y int }
+
+	for _, test := range []struct {
+		orientation HexOrientation
+		cell        position
+		want        []position
+	}{
+		// PointyTop rows are shoved right on odd rows, so the neighbors above and below lean left on even rows and right on
+		// odd ones.
+		{PointyTop, position{2, 2}, []position{{1, 1}, {2, 1}, {1, 2}, {3, 2}, {1, 3}, {2, 3}}},
+		{PointyTop, position{2, 3}, []position{{2, 2}, {3, 2}, {1, 3}, {3, 3}, {2, 4}, {3, 4}}},
+		// FlatTop columns are shoved down on odd columns, so the neighbors to either side lean up on even columns and down on
+		// odd ones.
+		{FlatTop, position{2, 2}, []position{{1, 1}, {1, 2}, {2, 1}, {2, 3}, {3, 1}, {3, 2}}},
+		{FlatTop, position{3, 2}, []position{{2, 2}, {2, 3}, {3, 1}, {3, 3}, {4, 2}, {4, 3}}},
+		// Neighbors outside of the HexGrid are left out.
+		{PointyTop, position{0, 0}, []position{{1, 0}, {0, 1}}},
+		{FlatTop, position{0, 0}, []position{{0, 1}, {1, 0}}},
+	} {
+
+		h := NewHexGrid(6, 6, 16, 16, test.orientation)
+		got := []position{}
+		for _, n := range h.Neighbors(h.Get(test.cell.x, test.cell.y)) {
+			got = append(got, position{n.X, n.Y})
+		}
+		for _, positions := range [][]position{got, test.want} {
+			sort.Slice(positions, func(i, j int) bool {
+				return positions[i].y < positions[j].y || (positions[i].y == positions[j].y && positions[i].x < positions[j].x)
+			})
+		}
+
+		if len(got) != len(test.want) {
+			t.Fatalf("orientation %d: neighbors of %v are %v, not %v", test.orientation, test.cell, got, test.want)
+		}
+		for i := range got {
+			if got[i] != test.want[i] {
+				t.Fatalf("orientation %d: neighbors of %v are %v, not %v", test.orientation, test.cell, got, test.want)
+			}
+		}
+
+	}
+
+	// Every Cell is a neighbor of its neighbors, a step away.
+	for _, orientation := range []HexOrientation{PointyTop, FlatTop} {
+		h := NewHexGrid(7, 6, 16, 16, orientation)
+		for _, cell := range h.Grid.AllCells() {
+			for _, n := range h.Neighbors(cell) {
+				if h.Distance(cell, n) != 1 {
+					t.Fatalf("orientation %d: neighbors %s and %s are %d steps apart", orientation, cell, n, h.Distance(cell, n))
+				}
+				back := false
+				for _, c := range h.Neighbors(n) {
+					back = back || c == cell
+				}
+				if !back {
+					t.Fatalf("orientation %d: %s neighbors %s, but not the other way around", orientation, cell, n)
+				}
+			}
+		}
+	}
+
+}
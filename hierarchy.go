@@ -0,0 +1,633 @@
+package paths
+
+import "math"
+
+// maxSingleEntrance is the length of an opening between two clusters past which it gets two entrances (one at each end)
+// rather than a single one in the middle.
+const maxSingleEntrance = 6
+
+// A Hierarchy speeds up pathfinding on large Grids using hierarchical pathfinding (HPA*). The Grid is divided into square
+// clusters, and the walkable openings between neighboring clusters (including diagonal ones, and the corners where clusters
+// meet, if the options allow moving diagonally) become entrances. Paths between the entrances of each
+// cluster are found ahead of time, forming a much smaller abstract graph. Searching that graph is quick, and its result is
+// refined back into a Path of Cells. Paths found this way are usually close to, though not always exactly, the cheapest ones.
+//
+// When Cells change, call UpdateCells() so that only the affected clusters are rebuilt (or call Watch() to have the Hierarchy do
+// that itself whenever the Grid records a change). A Hierarchy isn't safe to use from multiple goroutines at once.
+type Hierarchy struct {
+	Grid        *Grid
+	ClusterSize int
+	Options     *PathOptions
+
+	clusters                   []*cluster
+	clustersWide, clustersHigh int
+	borders                    map[[2]int][]transition
+	nodes                      map[*Cell]*abstractNode
+	pathfinder                 *Pathfinder
+	search                     indexSearch
+	watcher
+
+	// ids holds each abstractNode by its node in the abstract graph, with nil for nodes that were removed. Their numbers are kept
+	// in free to be reused.
+	ids  []*abstractNode
+	free []int
+}
+
+// A cluster is a square section of the Grid.
+type cluster struct {
+	bounds bounds
+	nodes  []*abstractNode
+}
+
+// A transition is a pair of walkable Cells on either side of the border between two clusters, which can be stepped between
+// orthogonally or diagonally.
+type transition struct {
+	a, b *Cell
+}
+
+// An abstractNode is an entrance Cell in the abstract graph. Edges lead to the other entrances of its cluster, while Exits
+// lead across borders into neighboring clusters.
+type abstractNode struct {
+	Cell  *Cell
+	Edges []*abstractEdge
+	Exits []*abstractEdge
+	id    int
+}
+
+// An abstractEdge connects two abstractNodes. Edges within a cluster store the Cells to move through, while edges between
+// clusters are a single step from one Cell to its neighbor.
+type abstractEdge struct {
+	From  *abstractNode
+	To    *abstractNode
+	Cost  float64
+	Cells []*Cell
+	inter bool
+}
+
+// edgeCost returns the cost of moving along the edge provided. Edges between clusters are a single step, costed as the Grid
+// currently is, so that they don't need to be updated when the Cells change.
+func (h *Hierarchy) edgeCost(e *abstractEdge) float64 {
+	if e.inter {
+		return h.Grid.moveCost(e.From.Cell, e.To.Cell, h.Options)
+	}
+	return e.Cost
+}
+
+// NewHierarchy returns a new Hierarchy for the Grid provided, divided into clusters of clusterSize x clusterSize Cells. options
+// controls how Paths are found, both within clusters and through the Hierarchy.
+func NewHierarchy(grid *Grid, clusterSize int, options *PathOptions) *Hierarchy {
+
+	h := &Hierarchy{
+		Grid:        grid,
+		ClusterSize: clusterSize,
+		Options:     options,
+		pathfinder:  NewPathfinder(grid),
+	}
+	h.target = h
+	h.Rebuild()
+	return h
+
+}
+
+// Rebuild rebuilds the entire Hierarchy from scratch. This is necessary if the Grid changes size.
+func (h *Hierarchy) Rebuild() {
+
+	size := h.ClusterSize
+	h.clustersWide = (h.Grid.Width() + size - 1) / size
+	h.clustersHigh = (h.Grid.Height() + size - 1) / size
+	h.clusters = make([]*cluster, h.clustersWide*h.clustersHigh)
+	h.borders = map[[2]int][]transition{}
+	h.nodes = map[*Cell]*abstractNode{}
+	h.ids = h.ids[:0]
+	h.free = h.free[:0]
+
+	dirty := map[int]bool{}
+
+	for cy := 0; cy < h.clustersHigh; cy++ {
+		for cx := 0; cx < h.clustersWide; cx++ {
+			b := bounds{cx * size, cy * size, min((cx+1)*size, h.Grid.Width()) - 1, min((cy+1)*size, h.Grid.Height()) - 1}
+			h.clusters[cy*h.clustersWide+cx] = &cluster{bounds: b}
+			dirty[cy*h.clustersWide+cx] = true
+		}
+	}
+
+	h.rebuild(dirty)
+
+}
+
+func (h *Hierarchy) watchedGrid() *Grid {
+	return h.Grid
+}
+
+// UpdateCells rebuilds the parts of the Hierarchy affected by the Cells provided, which should be called after changing their
+// walkability or Cost.
+func (h *Hierarchy) UpdateCells(cells ...*Cell) {
+
+	dirty := map[int]bool{}
+
+	for _, cell := range cells {
+		dirty[h.clusterIndex(cell)] = true
+	}
+
+	h.rebuild(dirty)
+
+}
+
+// clusterIndex returns the index of the cluster containing the Cell.
+func (h *Hierarchy) clusterIndex(cell *Cell) int {
+	return (cell.Y/h.ClusterSize)*h.clustersWide + cell.X/h.ClusterSize
+}
+
+// adjacentClusters returns the indices of the clusters to the left, right, top, and bottom of the one provided, as well as
+// those diagonal to it if the options allow moving diagonally.
+func (h *Hierarchy) adjacentClusters(c int) []int {
+
+	cx, cy := c%h.clustersWide, c/h.clustersWide
+	adjacent := make([]int, 0, 8)
+
+	if cx > 0 {
+		adjacent = append(adjacent, c-1)
+	}
+	if cx < h.clustersWide-1 {
+		adjacent = append(adjacent, c+1)
+	}
+	if cy > 0 {
+		adjacent = append(adjacent, c-h.clustersWide)
+	}
+	if cy < h.clustersHigh-1 {
+		adjacent = append(adjacent, c+h.clustersWide)
+	}
+
+	if h.Options.diagonals() {
+		for _, d := range [4][2]int{{-1, -1}, {1, -1}, {-1, 1}, {1, 1}} {
+			if x, y := cx+d[0], cy+d[1]; x >= 0 && y >= 0 && x < h.clustersWide && y < h.clustersHigh {
+				adjacent = append(adjacent, y*h.clustersWide+x)
+			}
+		}
+	}
+
+	return adjacent
+
+}
+
+// diagonalClusters returns whether the clusters provided meet only at a corner, and can be moved between there.
+func (h *Hierarchy) diagonalClusters(a, b int) bool {
+	dx, dy := a%h.clustersWide-b%h.clustersWide, a/h.clustersWide-b/h.clustersWide
+	return h.Options.diagonals() && abs(dx) == 1 && abs(dy) == 1
+}
+
+// borderKey returns the key of the border between two clusters.
+func borderKey(a, b int) [2]int {
+	if a > b {
+		a, b = b, a
+	}
+	return [2]int{a, b}
+}
+
+// findTransitions finds the transitions across the border between two neighboring clusters. Each unbroken opening along the
+// border gets an entrance in the middle, or one at each end if it's long. An opening is only unbroken as long as it can be
+// crossed in the same directions, so that one-way steps don't hide the ways across beside them. When moving diagonally, the
+// diagonal steps across the border make up openings of their own, and clusters that only meet at a corner can be crossed
+// between the Cells at that corner.
+func (h *Hierarchy) findTransitions(key [2]int) []transition {
+
+	// The first cluster is always above or to the left of the second.
+	a, b := h.clusters[key[0]].bounds, h.clusters[key[1]].bounds
+
+	if a.minX != b.minX && a.minY != b.minY {
+		t := transition{h.Grid.Get(a.minX, a.maxY), h.Grid.Get(b.maxX, b.minY)}
+		if b.minX > a.minX {
+			t = transition{h.Grid.Get(a.maxX, a.maxY), h.Grid.Get(b.minX, b.minY)}
+		}
+		if h.crossing(t) != 0 {
+			return []transition{t}
+		}
+		return nil
+	}
+
+	sideBySide := a.minY == b.minY
+
+	length := a.maxX - a.minX + 1
+	if sideBySide {
+		length = a.maxY - a.minY + 1
+	}
+
+	// pair returns the transition from the Cell at the index provided along the first cluster's side of the border to the one
+	// offset from it along the second's.
+	pair := func(i, offset int) transition {
+		if sideBySide {
+			return transition{h.Grid.Get(a.maxX, a.minY+i), h.Grid.Get(b.minX, a.minY+i+offset)}
+		}
+		return transition{h.Grid.Get(a.minX+i, a.maxY), h.Grid.Get(a.minX+i+offset, b.minY)}
+	}
+
+	offsets := []int{0}
+	if h.Options.diagonals() {
+		offsets = append(offsets, -1, 1)
+	}
+
+	transitions := []transition{}
+
+	for _, offset := range offsets {
+
+		first, last := max(0, -offset), min(length, length-offset)
+		run, runDirs := 0, 0
+
+		for i := first; i <= last; i++ {
+
+			dirs := 0
+			if i < last {
+				dirs = h.crossing(pair(i, offset))
+			}
+
+			if dirs != 0 && (run == 0 || dirs == runDirs) {
+				run++
+				runDirs = dirs
+				continue
+			}
+
+			if run > 0 {
+				start := i - run
+				if run < maxSingleEntrance {
+					transitions = append(transitions, pair(start+run/2, offset))
+				} else {
+					transitions = append(transitions, pair(start, offset), pair(i-1, offset))
+				}
+			}
+
+			// A change of direction starts a new opening right away.
+			run, runDirs = 0, dirs
+			if dirs != 0 {
+				run = 1
+			}
+
+		}
+
+	}
+
+	return transitions
+
+}
+
+// crossing returns which directions the transition provided can be crossed in, as a pair of bits.
+func (h *Hierarchy) crossing(t transition) int {
+	dirs := 0
+	if !math.IsInf(h.Grid.moveCost(t.a, t.b, h.Options), 1) {
+		dirs |= 1
+	}
+	if !math.IsInf(h.Grid.moveCost(t.b, t.a, h.Options), 1) {
+		dirs |= 2
+	}
+	return dirs
+}
+
+// rebuild recomputes the entrances along the borders of the dirty clusters and the exits of their neighbors, as well as the
+// edges of any cluster whose entrances (or contents) have changed.
+func (h *Hierarchy) rebuild(dirty map[int]bool) {
+
+	touched := map[int]bool{}
+
+	for c := range dirty {
+		touched[c] = true
+		adjacent := h.adjacentClusters(c)
+		for _, n := range adjacent {
+			key := borderKey(c, n)
+			h.borders[key] = h.findTransitions(key)
+			touched[n] = true
+			// A diagonal step between the corners of two clusters passes by the Cells at the corners of the two beside it, so the
+			// neighbors of this cluster that meet at one of its corners need their border found again, too.
+			for _, o := range adjacent {
+				if o > n && h.diagonalClusters(n, o) {
+					h.borders[borderKey(n, o)] = h.findTransitions(borderKey(n, o))
+				}
+			}
+		}
+	}
+
+	// First, update which Cells are entrances in each cluster, so that all of the nodes exist before any edges are made.
+	refresh := []int{}
+
+	for c := range touched {
+
+		cl := h.clusters[c]
+		entrances := h.entranceCells(c)
+
+		if !dirty[c] && len(entrances) == len(cl.nodes) {
+			same := true
+			for i, node := range cl.nodes {
+				if node.Cell != entrances[i] {
+					same = false
+					break
+				}
+			}
+			if same {
+				continue
+			}
+		}
+
+		refresh = append(refresh, c)
+
+		// Nodes that are still entrances are kept, since the edges of neighboring clusters may point to them.
+		kept := map[*Cell]bool{}
+		for _, cell := range entrances {
+			kept[cell] = true
+		}
+		for _, node := range cl.nodes {
+			if !kept[node.Cell] {
+				h.removeNode(node)
+			}
+		}
+
+		cl.nodes = cl.nodes[:0]
+		for _, cell := range entrances {
+			node, ok := h.nodes[cell]
+			if !ok {
+				node = h.addNode(cell)
+			}
+			cl.nodes = append(cl.nodes, node)
+		}
+
+	}
+
+	// Then connect them.
+	for c := range touched {
+		h.connectExits(c)
+	}
+	for _, c := range refresh {
+		h.connectEdges(c)
+	}
+
+}
+
+// addNode adds an abstractNode for the entrance Cell provided, reusing the number of a removed node if there is one.
+func (h *Hierarchy) addNode(cell *Cell) *abstractNode {
+
+	node := &abstractNode{Cell: cell, id: len(h.ids)}
+	if n := len(h.free); n > 0 {
+		node.id = h.free[n-1]
+		h.free = h.free[:n-1]
+		h.ids[node.id] = node
+	} else {
+		h.ids = append(h.ids, node)
+	}
+	h.nodes[cell] = node
+	return node
+
+}
+
+// removeNode removes the abstractNode provided, freeing up its number.
+func (h *Hierarchy) removeNode(node *abstractNode) {
+	delete(h.nodes, node.Cell)
+	h.ids[node.id] = nil
+	h.free = append(h.free, node.id)
+}
+
+// entranceCells returns the Cells in the cluster provided that are part of a transition to a neighboring cluster.
+func (h *Hierarchy) entranceCells(c int) []*Cell {
+
+	cells := []*Cell{}
+	b := h.clusters[c].bounds
+	added := map[*Cell]bool{}
+
+	for _, n := range h.adjacentClusters(c) {
+		for _, t := range h.borders[borderKey(c, n)] {
+			for _, cell := range [2]*Cell{t.a, t.b} {
+				if b.contains(cell) && !added[cell] {
+					added[cell] = true
+					cells = append(cells, cell)
+				}
+			}
+		}
+	}
+
+	return cells
+
+}
+
+// connectExits recreates the exits from each entrance in the cluster provided to the entrances of neighboring clusters.
+func (h *Hierarchy) connectExits(c int) {
+
+	cl := h.clusters[c]
+
+	for _, node := range cl.nodes {
+		node.Exits = node.Exits[:0]
+	}
+
+	for _, n := range h.adjacentClusters(c) {
+		for _, t := range h.borders[borderKey(c, n)] {
+			from, to := t.a, t.b
+			if !cl.bounds.contains(from) {
+				from, to = to, from
+			}
+			node := h.nodes[from]
+			node.Exits = append(node.Exits, &abstractEdge{From: node, To: h.nodes[to], Cells: []*Cell{from, to}, inter: true})
+		}
+	}
+
+}
+
+// connectEdges recreates the edges between the entrances within the cluster provided.
+func (h *Hierarchy) connectEdges(c int) {
+
+	cl := h.clusters[c]
+
+	for _, node := range cl.nodes {
+		node.Edges = node.Edges[:0]
+	}
+
+	// A Path from one entrance to another costs the same as the reverse, apart from the difference in the Costs of the entrances
+	// themselves, so we only need to search once for each pair. That isn't the case if the Grid has edge costs or the options
+	// have their own passability or cost functions, though.
+	directed := h.Grid.directed() || h.Options.custom()
+
+	for i, a := range cl.nodes {
+		for _, b := range cl.nodes[i+1:] {
+			if path, cost := h.pathfinder.getPathWithin(a.Cell, b.Cell, h.Options, &cl.bounds); path != nil {
+				a.Edges = append(a.Edges, &abstractEdge{From: a, To: b, Cost: cost, Cells: path.Cells})
+				if !directed {
+					reverse := &Path{Cells: append([]*Cell{}, path.Cells...)}
+					reverse.Reverse()
+					b.Edges = append(b.Edges, &abstractEdge{From: b, To: a, Cost: cost - b.Cell.Cost + a.Cell.Cost, Cells: reverse.Cells})
+				}
+			}
+			if directed {
+				if path, cost := h.pathfinder.getPathWithin(b.Cell, a.Cell, h.Options, &cl.bounds); path != nil {
+					b.Edges = append(b.Edges, &abstractEdge{From: b, To: a, Cost: cost, Cells: path.Cells})
+				}
+			}
+		}
+	}
+
+}
+
+// GetPath returns a Path, from the starting world X and Y position to the ending X and Y position, found through the
+// Hierarchy.
+func (h *Hierarchy) GetPath(startX, startY, endX, endY float64) *Path {
+
+	sx, sy := h.Grid.WorldToGrid(startX, startY)
+	sc := h.Grid.Get(sx, sy)
+	ex, ey := h.Grid.WorldToGrid(endX, endY)
+	ec := h.Grid.Get(ex, ey)
+
+	if sc != nil && ec != nil {
+		return h.GetPathFromCells(sc, ec)
+	}
+	return nil
+
+}
+
+// GetPathFromCells returns a Path, from the starting Cell to the destination Cell, found through the Hierarchy. As with
+// Grid.GetPathFromCells(), it returns nil if either Cell isn't walkable, and an empty Path if there's no route between them.
+func (h *Hierarchy) GetPathFromCells(start, dest *Cell) *Path {
+
+	if !start.Walkable || !dest.Walkable {
+		return nil
+	}
+
+	if start == dest {
+		return &Path{Cells: []*Cell{start}}
+	}
+
+	// The start and destination are temporarily connected to the entrances of their clusters, unless they're entrances
+	// themselves.
+	startNode, startIsEntrance := h.nodes[start]
+	if !startIsEntrance {
+		startNode = &abstractNode{Cell: start}
+	}
+	destNode, destIsEntrance := h.nodes[dest]
+	if !destIsEntrance {
+		destNode = &abstractNode{Cell: dest}
+	}
+
+	startEdges := []*abstractEdge{}
+	destEdges := map[*abstractNode]*abstractEdge{}
+
+	startCluster := h.clusters[h.clusterIndex(start)]
+	destCluster := h.clusters[h.clusterIndex(dest)]
+
+	if !startIsEntrance {
+		for _, node := range startCluster.nodes {
+			if path, cost := h.pathfinder.getPathWithin(start, node.Cell, h.Options, &startCluster.bounds); path != nil {
+				startEdges = append(startEdges, &abstractEdge{From: startNode, To: node, Cost: cost, Cells: path.Cells})
+			}
+		}
+	}
+
+	if !destIsEntrance {
+		for _, node := range destCluster.nodes {
+			if path, cost := h.pathfinder.getPathWithin(node.Cell, dest, h.Options, &destCluster.bounds); path != nil {
+				destEdges[node] = &abstractEdge{From: node, To: destNode, Cost: cost, Cells: path.Cells}
+			}
+		}
+	}
+
+	if startCluster == destCluster {
+		if path, cost := h.pathfinder.getPathWithin(start, dest, h.Options, &startCluster.bounds); path != nil {
+			startEdges = append(startEdges, &abstractEdge{From: startNode, To: destNode, Cost: cost, Cells: path.Cells})
+		}
+	}
+
+	// Now search the abstract graph. The start and destination are numbered after the Hierarchy's own nodes, unless they're
+	// entrances themselves.
+	if !startIsEntrance {
+		startNode.id = len(h.ids)
+	}
+	if !destIsEntrance {
+		destNode.id = len(h.ids) + 1
+	}
+
+	g := &abstractGraph{h: h, start: startNode, dest: destNode, startEdges: startEdges, destEdges: destEdges}
+	options := &PathOptions{TieBreak: h.Options.tieBreak(), HeuristicWeight: h.Options.heuristicWeight()}
+
+	result := h.search.search(g, startNode.id, destNode.id, options)
+	if result.Err != nil {
+		return &Path{}
+	}
+	return g.refine(result.Nodes)
+
+}
+
+// An abstractGraph is the Hierarchy's abstract graph as a Graph, so that it can be searched like any other, along with the
+// start and destination of a search through it and the edges temporarily connecting them. Each abstractNode's id is its node.
+type abstractGraph struct {
+	h          *Hierarchy
+	start      *abstractNode
+	dest       *abstractNode
+	startEdges []*abstractEdge
+	destEdges  map[*abstractNode]*abstractEdge
+	edges      []*abstractEdge
+}
+
+func (g *abstractGraph) Nodes() int {
+	return len(g.h.ids) + 2
+}
+
+// node returns the abstractNode numbered id.
+func (g *abstractGraph) node(id int) *abstractNode {
+	switch id {
+	case g.start.id:
+		return g.start
+	case g.dest.id:
+		return g.dest
+	}
+	return g.h.ids[id]
+}
+
+// edgesFrom returns the edges leading out of the abstractNode provided, including the temporary ones. The slice returned is
+// only valid until the next call.
+func (g *abstractGraph) edgesFrom(node *abstractNode) []*abstractEdge {
+	g.edges = append(append(g.edges[:0], node.Edges...), node.Exits...)
+	if node == g.start {
+		g.edges = append(g.edges, g.startEdges...)
+	}
+	if edge, ok := g.destEdges[node]; ok {
+		g.edges = append(g.edges, edge)
+	}
+	return g.edges
+}
+
+func (g *abstractGraph) AppendEdges(edges []Edge, node int) []Edge {
+	for _, edge := range g.edgesFrom(g.node(node)) {
+		if cost := g.h.edgeCost(edge); !math.IsInf(cost, 1) {
+			edges = append(edges, Edge{edge.To.id, cost})
+		}
+	}
+	return edges
+}
+
+func (g *abstractGraph) Estimate(from, to int) float64 {
+	if heuristic := g.h.Options.heuristic(); heuristic != nil {
+		return heuristic.Estimate(g.node(from).Cell, g.node(to).Cell)
+	}
+	return 0
+}
+
+func (g *abstractGraph) startCost(node int) float64 {
+	return g.node(node).Cell.Cost
+}
+
+// refine turns the route found through the abstract graph back into a Path of Cells.
+func (g *abstractGraph) refine(route []int) *Path {
+
+	path := &Path{Cells: []*Cell{g.start.Cell}}
+
+	for i := 1; i < len(route); i++ {
+
+		from, to := g.node(route[i-1]), g.node(route[i])
+
+		// The search moved along the cheapest edge between the two nodes.
+		var edge *abstractEdge
+		for _, e := range g.edgesFrom(from) {
+			if e.To == to && (edge == nil || g.h.edgeCost(e) < g.h.edgeCost(edge)) {
+				edge = e
+			}
+		}
+
+		// Each edge starts where the last one ended, so we skip its first Cell.
+		path.Cells = append(path.Cells, edge.Cells[1:]...)
+
+	}
+
+	return path
+
+}
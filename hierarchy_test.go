@@ -0,0 +1,143 @@
+package paths
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func TestHierarchyUpdateMatchesRebuild(t *testing.T) {
+
+	rng := rand.New(rand.NewSource(10))
+
+	for i := 0; i < 12; i++ {
+
+		m := randomGrid(rng, 40+rng.Intn(20), 30+rng.Intn(20), 0.25, i%3 != 0)
+		options := &PathOptions{Movement: Movement(i % 2), CornerCutting: CornerRule(i / 2 % 3), Heuristic: Manhattan}
+		if options.Movement == MoveDiagonal {
+			options.Heuristic = Octile
+		}
+
+		// Walls along the borders between clusters (and where four clusters meet) change their entrances, while opening them up
+		// again makes for long openings with an entrance at each end.
+		edits := []gridEdit{
+			{Rect{X: 8, Y: 0, W: 1, H: 24}, false, 1},
+			{Rect{X: 7, Y: 7, W: 2, H: 2}, false, 1},
+			{Rect{X: 8, Y: 8, W: 1, H: 1}, true, 1},
+			{Rect{X: 0, Y: 15, W: 24, H: 2}, false, 1},
+			{Rect{X: 0, Y: 0, W: 24, H: 24}, true, 1},
+			{Rect{X: 15, Y: 15, W: 2, H: 2}, true, 3},
+		}
+		for j := 0; j < 8; j++ {
+			edits = append(edits, randomEdit(rng, m, 6))
+		}
+
+		build := func() updater { return NewHierarchy(m, 8, options) }
+
+		testUpdates(m, i < 6, edits, build, func(got, want updater) {
+
+			// Paths through a Hierarchy aren't always the cheapest, but an updated Hierarchy should find ones just as cheap as a
+			// rebuilt one does.
+			h, fresh := got.(*Hierarchy), want.(*Hierarchy)
+
+			for j := 0; j < 20; j++ {
+
+				start, dest := randomCell(rng, m), randomCell(rng, m)
+				path, wantPath := h.GetPathFromCells(start, dest), fresh.GetPathFromCells(start, dest)
+
+				if (path == nil) != (wantPath == nil) || (path != nil && (len(path.Cells) == 0) != (len(wantPath.Cells) == 0)) {
+					t.Fatalf("updated Hierarchy found %v from %s to %s, but a rebuilt one found %v", path, start, dest, wantPath)
+				}
+				if path == nil || len(path.Cells) == 0 {
+					continue
+				}
+
+				cost, bad := pathCost(m, path, options)
+				if bad != "" || path.Cells[0] != start || path.Cells[len(path.Cells)-1] != dest {
+					t.Fatalf("updated Hierarchy's Path from %s to %s isn't valid (%s)", start, dest, bad)
+				}
+				if wantCost, _ := pathCost(m, wantPath, options); math.Abs(cost-wantCost) > 1e-9 {
+					t.Fatalf("updated Hierarchy's Path from %s to %s costs %f, but a rebuilt one's costs %f", start, dest, cost, wantCost)
+				}
+
+			}
+
+		})
+
+	}
+
+}
+
+func TestHierarchyUpdateCorner(t *testing.T) {
+
+	// The only Cells that are walkable are the corners of the top-right and bottom-left of the four clusters that meet in the
+	// middle, along with the corner of the top-left one once it's opened up. The diagonal step between the walkable corners
+	// passes by the top-left one, so whether that's walkable decides whether the step can be taken, even though it's in neither
+	// of their clusters.
+	m := NewGrid(16, 16, 16, 16)
+	options := &PathOptions{Movement: MoveDiagonal, CornerCutting: CornerCuttingOneWall}
+	start, dest := m.Get(8, 7), m.Get(7, 8)
+
+	edits := []gridEdit{
+		{Rect{X: 7, Y: 7, W: 1, H: 1}, true, 1},
+		{Rect{X: 7, Y: 7, W: 1, H: 1}, false, 1},
+		{Rect{X: 7, Y: 7, W: 1, H: 1}, true, 1},
+	}
+
+	for _, watching := range []bool{false, true} {
+		for _, cell := range m.AllCells() {
+			cell.Walkable = (cell.X == 7 && cell.Y == 8) || (cell.X == 8 && cell.Y == 7)
+		}
+		testUpdates(m, watching, edits, func() updater { return NewHierarchy(m, 8, options) }, func(got, want updater) {
+			path, wantPath := got.(*Hierarchy).GetPathFromCells(start, dest), want.(*Hierarchy).GetPathFromCells(start, dest)
+			if len(path.Cells) != len(wantPath.Cells) {
+				t.Fatalf("updated Hierarchy found %v from %s to %s, but a rebuilt one found %v", path.Cells, start, dest, wantPath.Cells)
+			}
+		})
+	}
+
+}
+
+func TestHierarchyMatchesSearch(t *testing.T) {
+
+	rng := rand.New(rand.NewSource(11))
+
+	for _, movement := range []Movement{MoveOrthogonal, MoveDiagonal} {
+		for _, corners := range []CornerRule{NoCornerCutting, CornerCuttingOneWall, CornerCuttingAllowed} {
+
+			options := &PathOptions{Movement: movement, CornerCutting: corners}
+
+			for i := 0; i < 20; i++ {
+
+				m := randomGrid(rng, 32, 32, 0.35, i%4 == 0)
+				h := NewHierarchy(m, 8, options)
+
+				for j := 0; j < 100; j++ {
+
+					start, dest := randomCell(rng, m), randomCell(rng, m)
+					want := m.SearchFromCells(start, dest, options)
+					got := h.GetPathFromCells(start, dest)
+
+					if (got == nil) != (want.Err == ErrStartBlocked || want.Err == ErrDestBlocked) || (got != nil && (len(got.Cells) == 0) != (want.Err != nil)) {
+						t.Fatalf("movement %d, corner rule %d: Hierarchy found %v from %s to %s, but searching found %v", movement, corners, got, start, dest, want.Err)
+					}
+					if got == nil || len(got.Cells) == 0 {
+						continue
+					}
+
+					// Paths through a Hierarchy can cost more than the cheapest one, but never less.
+					cost, bad := pathCost(m, got, options)
+					if bad != "" || got.Cells[0] != start || got.Cells[len(got.Cells)-1] != dest {
+						t.Fatalf("movement %d, corner rule %d: Hierarchy's Path from %s to %s isn't valid (%s)", movement, corners, start, dest, bad)
+					} else if cost < want.Cost-1e-9 {
+						t.Fatalf("movement %d, corner rule %d: Hierarchy's Path from %s to %s costs %f, less than the cheapest (%f)", movement, corners, start, dest, cost, want.Cost)
+					}
+
+				}
+
+			}
+
+		}
+	}
+
+}
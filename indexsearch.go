@@ -0,0 +1,335 @@
+package paths
+
+// startCoster is implemented by Graphs where starting on a node has a cost of its own (like the Cost of a Grid's starting
+// Cell).
+type startCoster interface {
+	startCost(node int) float64
+}
+
+// partialScorer is implemented by Graphs that score how close a node is to the destination for PathOptions.AllowPartial
+// differently than their Estimate.
+type partialScorer interface {
+	partialScore(node, dest int) float64
+}
+
+// routeEdger is implemented by Graphs whose Edges out of a node depend on the node it was reached from (like a Grid searched
+// with Jump Point Search, which only moves on in the directions worth checking). parent is -1 for the start node.
+type routeEdger interface {
+	appendEdgesFrom(edges []Edge, node, parent int) []Edge
+}
+
+// An indexSearch searches a Graph for the cheapest route between two nodes. It's the A* search behind all of paths' searches:
+// SearchGraph() and CompactGrid.Search() run it on their Graphs, while a Pathfinder runs it on a Grid (see gridGraph). It holds
+// on to the memory it uses to reuse it for the next search. Rather than storing search state for every node, it only stores a
+// slot number for each one, which points into a list of the nodes the current search has reached. A slot is only valid if the
+// record it points to belongs to the same node, so nothing needs to be cleared between searches.
+type indexSearch struct {
+	slots   []int32
+	records []indexRecord
+	open    indexHeap
+	edges   []Edge
+
+	// The state of the current search.
+	graph        Graph
+	router       routeEdger
+	scorer       partialScorer
+	dest         int
+	weight       float64
+	maxNodes     int
+	maxCost      float64
+	partial      bool
+	checked      int
+	limited      bool
+	found        int32
+	closest      int32
+	closestScore float64
+	done         bool
+}
+
+// An indexRecord is the search state of a node the current search has reached.
+type indexRecord struct {
+	node     int32
+	parent   int32
+	cost     float64
+	estimate float64
+	closed   bool
+}
+
+// slot returns the slot of the node provided, and whether the current search has reached it.
+func (s *indexSearch) slot(node int) (int32, bool) {
+	slot := s.slots[node]
+	return slot, slot >= 0 && int(slot) < len(s.records) && s.records[slot].node == int32(node)
+}
+
+// search searches the Graph for the cheapest route from the start node to the destination node, which are assumed to be
+// valid. The Duration of the result isn't set.
+func (s *indexSearch) search(g Graph, start, dest int, options *PathOptions) *GraphResult {
+
+	s.begin(g, start, dest, options)
+	for !s.step() {
+	}
+	result := s.result()
+
+	// The search may be pooled, so it shouldn't keep the Graph from being collected.
+	s.graph, s.router, s.scorer = nil, nil, nil
+	return result
+
+}
+
+// begin resets the search's state to start searching the Graph for a route from the start node to the destination node.
+func (s *indexSearch) begin(g Graph, start, dest int, options *PathOptions) {
+
+	s.reset(g.Nodes(), options.tieBreak())
+
+	s.graph = g
+	s.router, _ = g.(routeEdger)
+	s.scorer, _ = g.(partialScorer)
+	s.dest = dest
+	s.weight = options.heuristicWeight()
+	s.maxNodes = options.maxNodes()
+	s.maxCost = options.maxCost()
+	s.partial = options.allowPartial()
+	s.checked = 0
+	s.limited = false
+	s.found, s.closest = -1, -1
+	s.closestScore = 0
+	s.done = false
+
+	startCost := 0.0
+	if sc, ok := g.(startCoster); ok {
+		startCost = sc.startCost(start)
+	}
+
+	s.push(start, -1, startCost, g.Estimate(start, dest)*s.weight)
+
+}
+
+// reset clears out the records and open nodes of the last search, making sure there's a slot for each of the number of nodes
+// given.
+func (s *indexSearch) reset(nodes int, tieBreak TieBreak) {
+	// Stale slots are never valid, so the slots only need to grow.
+	if len(s.slots) < nodes {
+		s.slots = make([]int32, nodes)
+	}
+	s.records = s.records[:0]
+	s.open.items = s.open.items[:0]
+	s.open.tieBreak = tieBreak
+}
+
+// step checks the next most promising node, returning true once the search is finished.
+func (s *indexSearch) step() bool {
+
+	if s.done {
+		return true
+	}
+
+	// If there are no nodes left to check, there's no route to be found.
+	if s.open.Len() == 0 {
+		s.done = true
+		return true
+	}
+
+	item := s.open.pop()
+	record := &s.records[item.slot]
+
+	// A node can be pushed multiple times if a cheaper route to it is found; we only need to check it once.
+	if record.closed {
+		return false
+	}
+	record.closed = true
+	s.checked++
+
+	node, cost := int(record.node), record.cost
+
+	if s.partial {
+		score := 0.0
+		if s.scorer != nil {
+			score = s.scorer.partialScore(node, s.dest)
+		} else {
+			score = s.graph.Estimate(node, s.dest)
+		}
+		if s.closest < 0 || score < s.closestScore || (score == s.closestScore && cost < s.records[s.closest].cost) {
+			s.closest = item.slot
+			s.closestScore = score
+		}
+	}
+
+	if node == s.dest {
+		s.found = item.slot
+		s.done = true
+		return true
+	}
+
+	if s.maxNodes > 0 && s.checked >= s.maxNodes {
+		s.limited = true
+		s.done = true
+		return true
+	}
+
+	if s.router != nil {
+		parent := -1
+		if record.parent >= 0 {
+			parent = int(s.records[record.parent].node)
+		}
+		s.edges = s.router.appendEdgesFrom(s.edges[:0], node, parent)
+	} else {
+		s.edges = s.graph.AppendEdges(s.edges[:0], node)
+	}
+
+	for _, e := range s.edges {
+
+		next := cost + e.Cost
+		if s.maxCost > 0 && next > s.maxCost {
+			s.limited = true
+			continue
+		}
+
+		if slot, ok := s.slot(e.To); ok {
+			r := &s.records[slot]
+			if r.closed || r.cost <= next {
+				continue
+			}
+			r.cost = next
+			r.parent = item.slot
+			s.open.push(indexItem{slot, next, r.estimate})
+		} else {
+			s.push(e.To, item.slot, next, s.graph.Estimate(e.To, s.dest)*s.weight)
+		}
+
+	}
+
+	return false
+
+}
+
+// end returns the slot of the record the search ended on: the destination, or failing that, the node closest to it (in which
+// case partial is true). If there's neither, it returns the error to report instead.
+func (s *indexSearch) end() (slot int32, partial bool, err error) {
+	switch {
+	case s.found >= 0:
+		return s.found, false, nil
+	case s.closest >= 0:
+		return s.closest, true, nil
+	case s.limited:
+		return -1, false, ErrSearchLimit
+	}
+	return -1, false, ErrNoPath
+}
+
+// result returns the result of the current search. Its Duration isn't set.
+func (s *indexSearch) result() *GraphResult {
+
+	result := &GraphResult{SearchStats: SearchStats{NodesExpanded: s.checked}}
+
+	slot, partial, err := s.end()
+	if err != nil {
+		result.Err = err
+		return result
+	}
+
+	result.Nodes = s.route(slot)
+	result.Cost = s.records[slot].cost
+	result.Partial = partial
+	return result
+
+}
+
+// push records that the node provided has been reached, and adds it to the nodes to check.
+func (s *indexSearch) push(node int, parent int32, cost, estimate float64) {
+	slot := int32(len(s.records))
+	s.slots[node] = slot
+	s.records = append(s.records, indexRecord{node: int32(node), parent: parent, cost: cost, estimate: estimate})
+	s.open.push(indexItem{slot, cost, estimate})
+}
+
+// route returns the nodes leading from the start of the search to the record in the slot provided.
+func (s *indexSearch) route(slot int32) []int {
+
+	length := 0
+	for t := slot; t >= 0; t = s.records[t].parent {
+		length++
+	}
+
+	nodes := make([]int, length)
+	for t := slot; t >= 0; t = s.records[t].parent {
+		length--
+		nodes[length] = int(s.records[t].node)
+	}
+
+	return nodes
+
+}
+
+// An indexItem is an entry in an indexHeap. Since a node's cost can drop after it's been pushed, the cost it was pushed with is
+// stored alongside it. FlowFields, which keep their own state for each Cell, store the Cell's index in slot instead.
+type indexItem struct {
+	slot     int32
+	cost     float64
+	estimate float64
+}
+
+// indexHeap is a binary heap of indexItems. It's managed directly rather than through container/heap, which would allocate for
+// every item pushed.
+type indexHeap struct {
+	items    []indexItem
+	tieBreak TieBreak
+}
+
+func (h *indexHeap) Len() int { return len(h.items) }
+
+func (h *indexHeap) less(a, b indexItem) bool {
+	fa, fb := a.cost+a.estimate, b.cost+b.estimate
+	if fa == fb {
+		switch h.tieBreak {
+		case TieBreakFavorDestination:
+			return a.estimate < b.estimate
+		case TieBreakFavorStart:
+			return a.cost < b.cost
+		}
+	}
+	return fa < fb
+}
+
+func (h *indexHeap) push(item indexItem) {
+	h.items = append(h.items, item)
+	i := len(h.items) - 1
+	for i > 0 {
+		parent := (i - 1) / 2
+		if !h.less(item, h.items[parent]) {
+			break
+		}
+		h.items[i] = h.items[parent]
+		i = parent
+	}
+	h.items[i] = item
+}
+
+func (h *indexHeap) pop() indexItem {
+
+	top := h.items[0]
+	n := len(h.items) - 1
+	last := h.items[n]
+	h.items = h.items[:n]
+
+	if n > 0 {
+		i := 0
+		for {
+			child := 2*i + 1
+			if child >= n {
+				break
+			}
+			if child+1 < n && h.less(h.items[child+1], h.items[child]) {
+				child++
+			}
+			if !h.less(h.items[child], last) {
+				break
+			}
+			h.items[i] = h.items[child]
+			i = child
+		}
+		h.items[i] = last
+	}
+
+	return top
+
+}
@@ -0,0 +1,366 @@
+package paths
+
+// Jump Point Search (JPS) speeds up searching on Grids where every walkable Cell has the same Cost by "jumping" along straight
+// and diagonal lines, only stopping to check Cells where the route could branch off (jump points). This follows the variant of
+// JPS where diagonal movement can't cut corners, which matches NoCornerCutting.
+
+// maxJumpDistance is the furthest a JPS search jumps before stopping. In open areas, nothing stops a jump short of the edge of
+// the Grid, and since jumping diagonally scans sideways at every step, a single jump could otherwise cover a whole corner of the
+// Grid before the search even looks at the Cells around the start. Stopping early just adds a jump point, so Paths stay the
+// cheapest ones.
+const maxJumpDistance = 64
+
+// jumpDirections are the eight directions a search can jump in, in the order they're stored in a jumpTable.
+var jumpDirections = [8][2]int{{1, 0}, {-1, 0}, {0, 1}, {0, -1}, {1, 1}, {-1, 1}, {1, -1}, {-1, -1}}
+
+// jumpDirection returns the index of the direction provided in jumpDirections.
+func jumpDirection(dx, dy int) int {
+	for i, d := range jumpDirections {
+		if d[0] == dx && d[1] == dy {
+			return i
+		}
+	}
+	return -1
+}
+
+// A jumpTable stores, for each Cell in a Grid and each of the eight directions, how far a JPS+ search can jump. A positive value
+// is the distance to the next jump point in that direction, while zero or a negative value is the (negated) distance to the
+// last walkable Cell before a wall.
+type jumpTable struct {
+	width, height int
+	distances     [][8]int32
+	uniform       bool
+	cost          float64
+	version       uint64
+}
+
+// PrecomputeJumpPoints precomputes the jump distances used by AlgorithmJPSPlus. Searching with AlgorithmJPSPlus will do this
+// automatically the first time, and again after changes recorded by the Grid (see Grid.MarkChanged()); but if you change the
+// walkability or Cost of any Cells directly without recording it, you'll need to call PrecomputeJumpPoints again.
+func (m *Grid) PrecomputeJumpPoints() {
+	m.jumpMutex.Lock()
+	m.jumpTable = newJumpTable(m)
+	m.jumpMutex.Unlock()
+}
+
+// jumpPoints returns the Grid's jumpTable, precomputing it if necessary.
+func (m *Grid) jumpPoints() *jumpTable {
+
+	m.jumpMutex.Lock()
+	defer m.jumpMutex.Unlock()
+
+	if t := m.jumpTable; t == nil || t.width != m.Width() || t.height != m.Height() || t.version != m.version {
+		m.jumpTable = newJumpTable(m)
+	}
+	return m.jumpTable
+
+}
+
+func newJumpTable(m *Grid) *jumpTable {
+
+	w, h := m.Width(), m.Height()
+	table := &jumpTable{width: w, height: h, distances: make([][8]int32, w*h), version: m.version}
+	table.cost, table.uniform = m.findUniformCost()
+
+	// Cardinal directions come first, since the diagonals depend on the cardinal distances of the Cells they pass through. Each
+	// direction is swept from the far end, so that the next Cell's distance is always ready.
+	for d := range jumpDirections {
+
+		dx, dy := jumpDirections[d][0], jumpDirections[d][1]
+		diagonal := dx != 0 && dy != 0
+		horizontal, vertical := jumpDirection(dx, 0), jumpDirection(0, dy)
+
+		for j := 0; j < h; j++ {
+
+			for i := 0; i < w; i++ {
+
+				x, y := i, j
+				if dx > 0 {
+					x = w - 1 - i
+				}
+				if dy > 0 {
+					y = h - 1 - j
+				}
+
+				nx, ny := x+dx, y+dy
+				if !m.walkable(x, y) || !m.walkable(nx, ny) || (diagonal && (!m.walkable(nx, y) || !m.walkable(x, ny))) {
+					continue
+				}
+
+				next := table.distances[ny*w+nx]
+				dist := next[d]
+				if (!diagonal && m.forcedJump(nx, ny, dx, dy)) || (diagonal && (next[horizontal] > 0 || next[vertical] > 0)) {
+					dist = 1
+				} else if dist > 0 {
+					dist++
+				} else {
+					dist--
+				}
+				table.distances[y*w+x][d] = dist
+
+			}
+
+		}
+
+	}
+
+	return table
+
+}
+
+// walkable returns whether the Cell at the given position exists and is walkable.
+func (m *Grid) walkable(x, y int) bool {
+	c := m.Get(x, y)
+	return c != nil && c.Walkable
+}
+
+// uniformCosts returns the Cost all walkable Cells in the Grid share, and whether they do, as findUniformCost() does. Checking
+// means going through every Cell, so the answer is remembered until the Grid records a change, as with the jumpTable.
+func (m *Grid) uniformCosts() (float64, bool) {
+
+	m.jumpMutex.Lock()
+	defer m.jumpMutex.Unlock()
+
+	if !m.uniformChecked || m.uniformVersion != m.version {
+		m.uniformCost, m.uniform = m.findUniformCost()
+		m.uniformVersion = m.version
+		m.uniformChecked = true
+	}
+	return m.uniformCost, m.uniform
+
+}
+
+// findUniformCost returns the Cost all walkable Cells in the Grid share, and whether they do.
+func (m *Grid) findUniformCost() (float64, bool) {
+
+	first := true
+	cost := 0.0
+
+	for i, n := 0, m.Nodes(); i < n; i++ {
+		cell := m.GetIndex(i)
+		if cell == nil || !cell.Walkable {
+			continue
+		}
+		if first {
+			cost = cell.Cost
+			first = false
+		} else if cell.Cost != cost {
+			return 0, false
+		}
+	}
+
+	return cost, true
+
+}
+
+// forcedJump returns whether the Cell at the given position, having been reached by moving orthogonally in the given direction,
+// has a neighbor that can only be reached optimally through it (and so is a jump point).
+func (m *Grid) forcedJump(x, y, dx, dy int) bool {
+	if dx != 0 {
+		return (m.walkable(x, y-1) && !m.walkable(x-dx, y-1)) || (m.walkable(x, y+1) && !m.walkable(x-dx, y+1))
+	}
+	return (m.walkable(x-1, y) && !m.walkable(x-1, y-dy)) || (m.walkable(x+1, y) && !m.walkable(x+1, y-dy))
+}
+
+// canJump returns whether the current search can use Jump Point Search, which requires diagonal movement without corner cutting,
+// and for every step onto a walkable Cell to cost the same. It also returns the Cost the walkable Cells share.
+func (g *gridGraph) canJump(algorithm Algorithm) (float64, bool) {
+
+	if !g.options.diagonals() || g.options.cornerCutting() != NoCornerCutting || g.grid.directed() || g.options.custom() {
+		return 0, false
+	}
+
+	var cost float64
+	var uniform bool
+
+	if algorithm == AlgorithmJPSPlus {
+		g.jumpTable = g.grid.jumpPoints()
+		cost, uniform = g.jumpTable.cost, g.jumpTable.uniform
+	} else {
+		g.jumpTable = nil
+		cost, uniform = g.grid.uniformCosts()
+	}
+
+	// Jumps skip over Cells that a diagonal step could only be cheaper through if it cost less than an orthogonal one, or more
+	// than two of them.
+	diagonal := g.options.diagonalCost()
+	return cost, uniform && diagonal > 0 && diagonal < cost
+
+}
+
+// jump moves from the given position in the direction provided until it finds a jump point (or the destination), returning
+// nil if it runs into a wall first. Jumps stop after maxJumpDistance Cells, treating the Cell they stop at as a jump point.
+func (g *gridGraph) jump(x, y, dx, dy int) *Cell {
+
+	m := g.grid
+
+	for steps := 1; ; steps++ {
+
+		if !m.walkable(x, y) {
+			return nil
+		}
+
+		cell := m.Get(x, y)
+		if cell == g.dest {
+			return cell
+		}
+
+		if dx != 0 && dy != 0 {
+			// When moving diagonally, we have to check for jump points horizontally and vertically.
+			if g.jump(x+dx, y, dx, 0) != nil || g.jump(x, y+dy, 0, dy) != nil {
+				return cell
+			}
+			if !m.walkable(x+dx, y) || !m.walkable(x, y+dy) {
+				return nil
+			}
+		} else if m.forcedJump(x, y, dx, dy) {
+			return cell
+		}
+
+		if steps >= maxJumpDistance {
+			return cell
+		}
+
+		x += dx
+		y += dy
+
+	}
+
+}
+
+// jumpSearchDirections appends the directions worth searching in from the given Cell, having jumped to it from the parent
+// Cell provided (nil for the start), to the slice provided, pruning those that can be reached just as cheaply without passing
+// through the Cell.
+func (g *gridGraph) jumpSearchDirections(directions []int, cell, parent *Cell) []int {
+
+	if parent == nil {
+		for d := range jumpDirections {
+			directions = append(directions, d)
+		}
+		return directions
+	}
+
+	m := g.grid
+	x, y := cell.X, cell.Y
+	dx, dy := sign(x-parent.X), sign(y-parent.Y)
+
+	if dx != 0 && dy != 0 {
+		return append(directions, jumpDirection(0, dy), jumpDirection(dx, 0), jumpDirection(dx, dy))
+	}
+
+	// Moving orthogonally, we can continue on, or turn off to the sides (and diagonally towards them) if they're open.
+	sx, sy := dy, dx
+	directions = append(directions, jumpDirection(dx, dy))
+	for _, side := range [2]int{1, -1} {
+		if m.walkable(x+sx*side, y+sy*side) {
+			directions = append(directions, jumpDirection(sx*side, sy*side), jumpDirection(dx+sx*side, dy+sy*side))
+		}
+	}
+	return directions
+
+}
+
+// appendJumpSuccessors appends Edges to the jump points reachable from the given Cell (having jumped to it from the parent
+// Cell provided) to the slice provided, along with the cost of moving to each.
+func (g *gridGraph) appendJumpSuccessors(edges []Edge, cell, parent *Cell) []Edge {
+
+	x, y := cell.X, cell.Y
+	g.directions = g.jumpSearchDirections(g.directions[:0], cell, parent)
+
+	for _, d := range g.directions {
+
+		dx, dy := jumpDirections[d][0], jumpDirections[d][1]
+
+		var next *Cell
+		if g.jumpTable != nil {
+			next = g.jumpPlus(x, y, d)
+		} else if dx == 0 || dy == 0 || (g.grid.walkable(x+dx, y) && g.grid.walkable(x, y+dy)) {
+			next = g.jump(x+dx, y+dy, dx, dy)
+		}
+
+		if next != nil {
+			edges = append(edges, Edge{g.grid.Index(next.X, next.Y), g.jumpCost(cell, next)})
+		}
+
+	}
+
+	return edges
+
+}
+
+// jumpPlus uses the precomputed jumpTable to find the next jump point (or the destination) from the given position in the
+// direction provided, returning nil if there is none.
+func (g *gridGraph) jumpPlus(x, y, d int) *Cell {
+
+	dist := int(g.jumpTable.distances[y*g.jumpTable.width+x][d])
+	reach := dist
+	if reach < 0 {
+		reach = -reach
+	}
+
+	dx, dy := jumpDirections[d][0], jumpDirections[d][1]
+	tx, ty := g.dest.X-x, g.dest.Y-y
+
+	if dx == 0 || dy == 0 {
+
+		// If the destination lies in this direction before any wall or jump point, we can go straight to it.
+		if (dx == 0 && tx == 0 && sign(ty) == dy && abs(ty) <= reach) || (dy == 0 && ty == 0 && sign(tx) == dx && abs(tx) <= reach) {
+			return g.dest
+		}
+
+	} else if sign(tx) == dx && sign(ty) == dy {
+
+		// If the destination is in this direction diagonally, we may need to stop diagonally in line with it, so that we can then
+		// move straight to it.
+		if steps := min(abs(tx), abs(ty)); steps <= reach {
+			return g.grid.Get(x+dx*steps, y+dy*steps)
+		}
+
+	}
+
+	if dist > 0 {
+		return g.grid.Get(x+dx*dist, y+dy*dist)
+	}
+	return nil
+
+}
+
+// jumpCost returns the cost of moving in a straight line from one Cell to another, assuming that every Cell along the way has
+// the same Cost.
+func (g *gridGraph) jumpCost(from, to *Cell) float64 {
+	steps := float64(max(abs(to.X-from.X), abs(to.Y-from.Y)))
+	if from.X != to.X && from.Y != to.Y {
+		return steps * (to.Cost + g.options.diagonalCost())
+	}
+	return steps * to.Cost
+}
+
+func sign(x int) int {
+	if x < 0 {
+		return -1
+	} else if x > 0 {
+		return 1
+	}
+	return 0
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
+
+func min(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func max(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
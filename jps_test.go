@@ -0,0 +1,90 @@
+package paths
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+// pathCost returns the cost of moving along the Path provided, stepping from each Cell to the next, or an error message if
+// any step isn't between neighboring Cells or can't be taken with the options provided.
+func pathCost(m *Grid, path *Path, options *PathOptions) (float64, string) {
+	cost := path.Cells[0].Cost
+	for i := 1; i < len(path.Cells); i++ {
+		from, to := path.Cells[i-1], path.Cells[i]
+		if abs(to.X-from.X) > 1 || abs(to.Y-from.Y) > 1 || from == to || (!options.diagonals() && from.X != to.X && from.Y != to.Y) {
+			return 0, "steps from " + from.String() + " to " + to.String()
+		}
+		step := m.moveCost(from, to, options)
+		if math.IsInf(step, 1) {
+			return 0, "is blocked from " + from.String() + " to " + to.String()
+		}
+		cost += step
+	}
+	return cost, ""
+}
+
+func TestJPSMatchesDijkstra(t *testing.T) {
+
+	rng := rand.New(rand.NewSource(5))
+
+	// JPS only works for DiagonalCosts between 0 and the Cost of the Cells; for the rest, it falls back to A*.
+	diagonalCosts := []float64{0, 0.05, 0.9, 1, 2, 5}
+
+	for i := 0; i < 150; i++ {
+
+		// Every tenth Grid has varying Costs, which JPS falls back to A* for, while every seventh has Cells that all cost 3.
+		m := randomGrid(rng, 5+rng.Intn(150), 5+rng.Intn(150), rng.Float64()*0.4, i%10 == 0)
+		if i%7 == 0 {
+			for _, cell := range m.AllCells() {
+				cell.Cost = 3
+			}
+		}
+
+		for j := 0; j < 6; j++ {
+
+			start, dest := randomCell(rng, m), randomCell(rng, m)
+			diagonalCost := diagonalCosts[j]
+			want := m.SearchFromCells(start, dest, &PathOptions{Movement: MoveDiagonal, DiagonalCost: diagonalCost})
+
+			for _, algorithm := range []Algorithm{AlgorithmJPS, AlgorithmJPSPlus} {
+
+				options := &PathOptions{Movement: MoveDiagonal, DiagonalCost: diagonalCost, Algorithm: algorithm}
+				got := m.SearchFromCells(start, dest, options)
+				if !sameCost(got, want) {
+					t.Fatalf("grid %d: algorithm %d with a DiagonalCost of %f found %v (cost %f) from %v to %v, but Dijkstra found %v (cost %f)", i, algorithm, diagonalCost, got.Err, got.Cost, start, dest, want.Err, want.Cost)
+				}
+
+				if got.Path == nil {
+					continue
+				}
+				if cost, err := pathCost(m, got.Path, options); err != "" {
+					t.Fatalf("grid %d: algorithm %d returned a Path that %s", i, algorithm, err)
+				} else if math.Abs(cost-got.Cost) > 1e-9 {
+					t.Fatalf("grid %d: algorithm %d returned a Path costing %f, but reported %f", i, algorithm, cost, got.Cost)
+				}
+
+			}
+
+		}
+
+	}
+
+}
+
+func BenchmarkJPSShortSearch(b *testing.B) {
+	m := NewGrid(1000, 1000, 16, 16)
+	start, dest := m.Get(10, 10), m.Get(13, 12)
+	for _, algorithm := range []struct {
+		name      string
+		algorithm Algorithm
+	}{{"AStar", AlgorithmAStar}, {"JPS", AlgorithmJPS}, {"JPSPlus", AlgorithmJPSPlus}} {
+		options := &PathOptions{Movement: MoveDiagonal, Heuristic: Octile, Algorithm: algorithm.algorithm}
+		b.Run(algorithm.name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				m.SearchFromCells(start, dest, options)
+			}
+		})
+	}
+}
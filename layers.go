@@ -0,0 +1,230 @@
+package paths
+
+import (
+	"math"
+	"time"
+)
+
+// A LayeredGrid is a stack of Grids (layers), like the floors of a building, connected by Cells that lead from one layer to
+// another, like stairs, ladders, or teleporters. Each connection has its own cost. The layers don't have to be the same size.
+type LayeredGrid struct {
+	Layers []*Grid
+
+	// offsets holds the node number of the first Cell of each layer, while connections holds the Edges leading between layers
+	// by node.
+	offsets     []int
+	connections map[int][]Edge
+}
+
+// A LayerStep is a Cell on a particular layer of a LayeredGrid.
+type LayerStep struct {
+	Layer int
+	Cell  *Cell
+}
+
+// A LayerPath is a sequence of steps across a LayeredGrid. It works like a Path, but each step also notes the layer it's on.
+type LayerPath struct {
+	Steps        []LayerStep
+	CurrentIndex int
+}
+
+// A LayerResult is the outcome of a search on a LayeredGrid, along with some statistics about it. It's the same as a
+// PathResult, other than the Path being a LayerPath.
+type LayerResult struct {
+	// Path is the LayerPath found, or nil if Err is set.
+	Path *LayerPath
+	SearchStats
+}
+
+// NewLayeredGrid returns a new LayeredGrid made of the layers provided, from the bottom up. Connect the layers using Connect()
+// or ConnectRunes().
+func NewLayeredGrid(layers ...*Grid) *LayeredGrid {
+
+	l := &LayeredGrid{
+		Layers:      layers,
+		offsets:     make([]int, len(layers)+1),
+		connections: map[int][]Edge{},
+	}
+
+	for i, layer := range layers {
+		l.offsets[i+1] = l.offsets[i] + layer.Width()*layer.Height()
+	}
+
+	return l
+
+}
+
+// Connect connects a Cell on one layer to a Cell on another, one way, so that a Path can step from one to the other at the
+// cost provided (rather than the Cost of the Cell being stepped onto). Connect both ways for stairs that can be walked up and
+// down.
+func (l *LayeredGrid) Connect(from, to LayerStep, cost float64) {
+	f := l.node(from.Layer, from.Cell)
+	l.connections[f] = append(l.connections[f], Edge{l.node(to.Layer, to.Cell), cost})
+}
+
+// ConnectRunes connects every Cell with the from rune to the Cell at the same position on the layers directly above and below
+// it, if that Cell has the to rune, at the cost provided. For example, ConnectRunes('H', 'H', 2) connects ladders stacked
+// across floors in both directions, while ConnectRunes('>', '<', 1) leads from stairs going down ('>') to where they arrive on
+// the neighboring floors ('<'). Connections can be made across teleporters or stairs that don't line up using Connect().
+func (l *LayeredGrid) ConnectRunes(from, to rune, cost float64) {
+
+	for i, layer := range l.Layers {
+		for _, cell := range layer.CellsByRune(from) {
+			for _, j := range [2]int{i - 1, i + 1} {
+				if j < 0 || j >= len(l.Layers) {
+					continue
+				}
+				if other := l.Layers[j].Get(cell.X, cell.Y); other != nil && other.Rune == to {
+					l.Connect(LayerStep{i, cell}, LayerStep{j, other}, cost)
+				}
+			}
+		}
+	}
+
+}
+
+// Disconnect removes all connections leading from the Cell on the layer provided.
+func (l *LayeredGrid) Disconnect(layer int, cell *Cell) {
+	delete(l.connections, l.node(layer, cell))
+}
+
+// node returns the node number of the Cell on the layer provided.
+func (l *LayeredGrid) node(layer int, cell *Cell) int {
+	return l.offsets[layer] + l.Layers[layer].Index(cell.X, cell.Y)
+}
+
+// step returns the layer and Cell of the node provided.
+func (l *LayeredGrid) step(node int) LayerStep {
+	layer := 0
+	for node >= l.offsets[layer+1] {
+		layer++
+	}
+	return LayerStep{layer, l.Layers[layer].GetIndex(node - l.offsets[layer])}
+}
+
+// GetPath returns a LayerPath from the starting Cell to the destination Cell, which can be on different layers. options
+// controls how the Path moves within each layer, as with Grid.GetPathFromCells(), though Algorithm doesn't apply. A Heuristic
+// only considers the positions of Cells, not their layers, so leave it out if layers are connected by teleporters that lead
+// far away. As with Grid.GetPathFromCells(), GetPath returns nil if either Cell isn't walkable, and an empty LayerPath if no
+// Path could be found.
+func (l *LayeredGrid) GetPath(start, dest LayerStep, options *PathOptions) *LayerPath {
+	result := l.Search(start, dest, options)
+	switch result.Err {
+	case nil:
+		return result.Path
+	case ErrNoPath, ErrSearchLimit:
+		return &LayerPath{}
+	}
+	return nil
+}
+
+// Search searches for a LayerPath from the starting Cell to the destination Cell, as with GetPath(), and returns the result.
+func (l *LayeredGrid) Search(start, dest LayerStep, options *PathOptions) *LayerResult {
+
+	began := time.Now()
+
+	if start.Layer < 0 || dest.Layer < 0 || start.Layer >= len(l.Layers) || dest.Layer >= len(l.Layers) {
+		return &LayerResult{SearchStats: SearchStats{Err: ErrOutOfBounds}}
+	}
+	if err := checkEnds(start.Cell, dest.Cell); err != nil && (err != ErrDestBlocked || !options.allowPartial()) {
+		return &LayerResult{SearchStats: SearchStats{Err: err}}
+	}
+
+	found := SearchGraph(&layeredGraph{l, options, nil}, l.node(start.Layer, start.Cell), l.node(dest.Layer, dest.Cell), options)
+
+	result := &LayerResult{SearchStats: found.SearchStats}
+	if found.Nodes != nil {
+		result.Path = &LayerPath{Steps: make([]LayerStep, len(found.Nodes))}
+		for i, n := range found.Nodes {
+			result.Path.Steps[i] = l.step(n)
+		}
+	}
+	result.Duration = time.Since(began)
+	return result
+
+}
+
+// layeredGraph is a LayeredGrid searched using a particular set of PathOptions.
+type layeredGraph struct {
+	*LayeredGrid
+	options   *PathOptions
+	neighbors []neighbor
+}
+
+func (g *layeredGraph) Nodes() int {
+	return g.offsets[len(g.Layers)]
+}
+
+func (g *layeredGraph) AppendEdges(edges []Edge, node int) []Edge {
+
+	step := g.step(node)
+	layer := g.Layers[step.Layer]
+
+	g.neighbors = layer.appendNeighbors(g.neighbors[:0], step.Cell, g.options)
+	for _, n := range g.neighbors {
+		edges = append(edges, Edge{g.offsets[step.Layer] + layer.Index(n.Cell.X, n.Cell.Y), n.Cost})
+	}
+
+	for _, e := range g.connections[node] {
+		to := g.step(e.To).Cell
+		if !to.Walkable || !g.options.passable(step.Cell, to) {
+			continue
+		}
+		if cost := g.options.stepCost(step.Cell, to, e.Cost); !math.IsInf(cost, 1) {
+			edges = append(edges, Edge{e.To, cost})
+		}
+	}
+
+	return edges
+
+}
+
+func (g *layeredGraph) Estimate(from, to int) float64 {
+	if h := g.options.heuristic(); h != nil {
+		return h.Estimate(g.step(from).Cell, g.step(to).Cell)
+	}
+	return 0
+}
+
+func (g *layeredGraph) startCost(node int) float64 {
+	return g.step(node).Cell.Cost
+}
+
+func (g *layeredGraph) partialScore(node, dest int) float64 {
+	return g.options.partialScore(g.step(node).Cell, g.step(dest).Cell)
+}
+
+// Current returns the current step on the LayerPath.
+func (p *LayerPath) Current() LayerStep {
+	return p.Get(p.CurrentIndex)
+}
+
+// Next returns the next step on the LayerPath. If the LayerPath is at the end, the step returned has a nil Cell.
+func (p *LayerPath) Next() LayerStep {
+	return p.Get(p.CurrentIndex + 1)
+}
+
+// Advance advances the LayerPath by one step.
+func (p *LayerPath) Advance() {
+	if p.CurrentIndex < len(p.Steps)-1 {
+		p.CurrentIndex++
+	}
+}
+
+// Get returns the step at the index provided, or a LayerStep with a nil Cell if the index is outside of the LayerPath.
+func (p *LayerPath) Get(index int) LayerStep {
+	if index < 0 || index >= len(p.Steps) {
+		return LayerStep{}
+	}
+	return p.Steps[index]
+}
+
+// Length returns the number of steps in the LayerPath.
+func (p *LayerPath) Length() int {
+	return len(p.Steps)
+}
+
+// AtEnd returns whether the LayerPath is at its last step.
+func (p *LayerPath) AtEnd() bool {
+	return p.CurrentIndex >= len(p.Steps)-1
+}
@@ -0,0 +1,125 @@
+package paths
+
+import (
+	"testing"
+)
+
+// connection returns the cost of the connection leading from one LayerStep to another, and whether there is one.
+func connection(l *LayeredGrid, from, to LayerStep) (float64, bool) {
+	for _, e := range l.connections[l.node(from.Layer, from.Cell)] {
+		if e.To == l.node(to.Layer, to.Cell) {
+			return e.Cost, true
+		}
+	}
+	return 0, false
+}
+
+func TestConnectRunes(t *testing.T) {
+
+	// A ladder ('H') runs up all three floors, while the stairs on the middle floor ('>') lead down to the bottom floor and up
+	// to the top one ('<').
+	l := NewLayeredGrid(
+		NewGridFromStringArrays([]string{"<  H"}, 16, 16),
+		NewGridFromStringArrays([]string{">  H"}, 16, 16),
+		NewGridFromStringArrays([]string{"<  H"}, 16, 16),
+	)
+	l.ConnectRunes('H', 'H', 2)
+	l.ConnectRunes('>', '<', 1)
+
+	ladder := func(layer int) LayerStep { return LayerStep{layer, l.Layers[layer].Get(3, 0)} }
+	stairs := func(layer int) LayerStep { return LayerStep{layer, l.Layers[layer].Get(0, 0)} }
+
+	for _, test := range []struct {
+		from, to  LayerStep
+		cost      float64
+		connected bool
+	}{
+		{ladder(0), ladder(1), 2, true},
+		{ladder(1), ladder(0), 2, true},
+		{ladder(1), ladder(2), 2, true},
+		{ladder(2), ladder(1), 2, true},
+		// Runes only connect to the layers directly above and below them.
+		{ladder(0), ladder(2), 0, false},
+		{stairs(1), stairs(0), 1, true},
+		{stairs(1), stairs(2), 1, true},
+		// Connections from one rune to another only lead one way.
+		{stairs(0), stairs(1), 0, false},
+		{stairs(2), stairs(1), 0, false},
+	} {
+		if cost, connected := connection(l, test.from, test.to); connected != test.connected || cost != test.cost {
+			t.Fatalf("connection from %s on layer %d to %s on layer %d: got %t (cost %f), want %t (cost %f)", test.from.Cell, test.from.Layer, test.to.Cell, test.to.Layer, connected, cost, test.connected, test.cost)
+		}
+	}
+
+	// Disconnecting a Cell only removes the connections leading from it.
+	l.Disconnect(1, ladder(1).Cell)
+	if _, connected := connection(l, ladder(1), ladder(2)); connected {
+		t.Fatalf("ladder on layer 1 is still connected after disconnecting it")
+	}
+	if _, connected := connection(l, ladder(2), ladder(1)); !connected {
+		t.Fatalf("disconnecting the ladder on layer 1 disconnected the one on layer 2")
+	}
+
+}
+
+func TestLayeredPath(t *testing.T) {
+
+	// The bottom floor is split by a wall, so the only way across is to climb a ladder ('H') and cross the floor above.
+	l := NewLayeredGrid(
+		NewGridFromStringArrays([]string{
+			"  x  ",
+			"H x H",
+			"  x  ",
+		}, 16, 16),
+		NewGridFromStringArrays([]string{
+			"     ",
+			"H   H",
+			"     ",
+		}, 16, 16),
+	)
+	l.Layers[0].SetWalkable('x', false)
+	l.ConnectRunes('H', 'H', 3)
+
+	start, dest := LayerStep{0, l.Layers[0].Get(0, 0)}, LayerStep{0, l.Layers[0].Get(4, 0)}
+	result := l.Search(start, dest, nil)
+	if result.Err != nil {
+		t.Fatalf("search across layers failed: %v", result.Err)
+	}
+
+	steps := result.Path.Steps
+	if steps[0] != start || steps[len(steps)-1] != dest {
+		t.Fatalf("LayerPath runs from %s to %s, not from %s to %s", steps[0].Cell, steps[len(steps)-1].Cell, start.Cell, dest.Cell)
+	}
+
+	// Each step is either to a neighboring Cell on the same layer, or up or down a ladder.
+	changes := 0
+	for i := 1; i < len(steps); i++ {
+		prev, step := steps[i-1], steps[i]
+		if prev.Layer != step.Layer {
+			if _, connected := connection(l, prev, step); !connected {
+				t.Fatalf("LayerPath moves from %s on layer %d to %s on layer %d, which aren't connected", prev.Cell, prev.Layer, step.Cell, step.Layer)
+			}
+			changes++
+		} else if abs(prev.Cell.X-step.Cell.X)+abs(prev.Cell.Y-step.Cell.Y) != 1 {
+			t.Fatalf("LayerPath steps from %s to %s on layer %d", prev.Cell, step.Cell, step.Layer)
+		}
+	}
+	if changes != 2 {
+		t.Fatalf("LayerPath changes layers %d times, not 2", changes)
+	}
+
+	// The start, a step to the ladder, up it, four steps across, down the other ladder, and a step to the destination.
+	if result.Cost != 1+1+3+4+3+1 {
+		t.Fatalf("LayerPath costs %f, not %d", result.Cost, 1+1+3+4+3+1)
+	}
+
+	// Without a way back down, there's no way across.
+	l.Disconnect(1, l.Layers[1].Get(4, 1))
+	if result := l.Search(start, dest, nil); result.Err != ErrNoPath {
+		t.Fatalf("search across layers without a way down returned %v, not ErrNoPath", result.Err)
+	}
+	if path := l.GetPath(start, dest, nil); path == nil || path.Length() != 0 {
+		t.Fatalf("GetPath() without a way down should return an empty LayerPath, not %v", path)
+	}
+
+}
@@ -0,0 +1,195 @@
+package paths
+
+// DefaultDiagonalCost is the additional cost of moving diagonally used when PathOptions.DiagonalCost is left at 0. Diagonal
+// movement is slightly slower, so straightaways are prioritized if possible.
+const DefaultDiagonalCost = .414
+
+// Movement indicates which directions a Path can move in from one Cell to the next.
+type Movement int
+
+const (
+	// MoveOrthogonal allows moving up, down, left, and right.
+	MoveOrthogonal Movement = iota
+	// MoveDiagonal allows moving up, down, left, and right, as well as diagonally.
+	MoveDiagonal
+)
+
+// CornerRule indicates whether diagonal movement can "cut" past walls that are positioned diagonally.
+type CornerRule int
+
+const (
+	// NoCornerCutting only allows moving diagonally if both Cells orthogonally adjacent to the move are walkable.
+	NoCornerCutting CornerRule = iota
+	// CornerCuttingOneWall allows moving diagonally if at least one of the Cells orthogonally adjacent to the move is walkable.
+	CornerCuttingOneWall
+	// CornerCuttingAllowed allows moving diagonally regardless of the Cells orthogonally adjacent to the move.
+	CornerCuttingAllowed
+)
+
+// TieBreak indicates how to choose between Cells that are equally promising during a search.
+type TieBreak int
+
+const (
+	// TieBreakNone doesn't favor either Cell.
+	TieBreakNone TieBreak = iota
+	// TieBreakFavorDestination favors the Cell that's estimated to be closer to the destination. When used with a Heuristic, this
+	// usually cuts down on the number of Cells checked considerably.
+	TieBreakFavorDestination
+	// TieBreakFavorStart favors the Cell that's closer to the start.
+	TieBreakFavorStart
+)
+
+// Algorithm indicates which search algorithm is used to find a Path.
+type Algorithm int
+
+const (
+	// AlgorithmAStar checks Cells one at a time, guided by the Heuristic in use (if any). It works with any PathOptions.
+	AlgorithmAStar Algorithm = iota
+	// AlgorithmJPS uses Jump Point Search, which jumps along straight and diagonal lines, only stopping at Cells where the
+	// route could branch off. This is much faster than AlgorithmAStar on Grids where every walkable Cell has the same Cost.
+	// JPS requires MoveDiagonal and NoCornerCutting, with a DiagonalCost above 0 and below the Cost of the walkable Cells; if
+	// those aren't set, some walkable Cells have different Costs, or steps have costs of their own (from edge costs,
+	// EdgeCostFunc, PassableFunc, or CostFunc), the search falls back to AlgorithmAStar. If no Heuristic is set, an octile one
+	// using the Cells' Cost and the DiagonalCost is. Whether the Costs are all the same is remembered until the Grid
+	// records a change, so after changing the Cost of Cells directly, call Grid.MarkChanged() with them.
+	AlgorithmJPS
+	// AlgorithmJPSPlus is Jump Point Search using jump distances precomputed for the Grid (see Grid.PrecomputeJumpPoints()),
+	// which is faster still. It has the same requirements as AlgorithmJPS.
+	AlgorithmJPSPlus
+)
+
+// PathOptions controls how a Path is found. The zero value is ready to use, and finds the cheapest Path moving orthogonally,
+// checking Cells evenly in all directions. A nil *PathOptions is the same as the zero value.
+type PathOptions struct {
+	// Movement controls the directions the Path can move in.
+	Movement Movement
+	// DiagonalCost is added to the Cost of a Cell when moving to it diagonally. If 0, DefaultDiagonalCost is used.
+	DiagonalCost float64
+	// CornerCutting controls whether diagonal movement is allowed "through" walls that are positioned diagonally.
+	CornerCutting CornerRule
+	// Heuristic, if set, guides the search towards the destination (turning it into an A* search), so fewer Cells are checked.
+	Heuristic Heuristic
+	// HeuristicWeight scales the Heuristic's estimate. A weight above 1 performs a weighted A* search, which checks fewer Cells
+	// at the expense of possibly returning a slightly more costly Path. If 0, a weight of 1 is used.
+	HeuristicWeight float64
+	// MaxNodes is the maximum number of Cells the search can check before giving up. If 0, there is no limit.
+	MaxNodes int
+	// MaxCost is the maximum total cost a Path can have. If 0, there is no limit.
+	MaxCost float64
+	// TieBreak controls how to choose between equally promising Cells during the search.
+	TieBreak TieBreak
+	// Algorithm controls which search algorithm is used.
+	Algorithm Algorithm
+	// AllowPartial, if true, returns a Path to the reachable Cell closest to the destination when the destination can't be
+	// reached (or isn't walkable), rather than no Path at all. The PathResult is then marked as Partial. With AlgorithmJPS and
+	// AlgorithmJPSPlus, only the jump points the search stops at are considered, so the Cell chosen may not be the closest
+	// reachable one.
+	AllowPartial bool
+	// PartialScore scores how close a Cell is to the destination when AllowPartial is set; the Cell with the lowest score is
+	// chosen. If nil, the Heuristic is used (or Euclidean, if there's no Heuristic).
+	PartialScore func(cell, dest *Cell) float64
+
+	// Agent is whatever the Path is being found for (a unit, or a kind of unit, like a boat, a tank, or something that flies).
+	// It isn't used by the search itself; it's just passed along to PassableFunc and CostFunc.
+	Agent interface{}
+	// PassableFunc, if set, is called for every step between neighboring Cells during the search, with the Cell being stepped
+	// from, the one being stepped onto, and the Agent. If it returns false, the step can't be taken. This is checked on top of
+	// the Cells being walkable, so non-walkable Cells remain blocked for everyone; it also decides whether the Cells beside a
+	// diagonal step count as walls for CornerCutting. This way, a Grid can be shared between different kinds of units, with each
+	// one's PathOptions deciding where it can go (boats only on water, say).
+	PassableFunc func(from, to *Cell, agent interface{}) bool
+	// CostFunc, if set, is called for every step between neighboring Cells during the search, with the Cell being stepped
+	// from, the one being stepped onto, the cost the step would normally have (after any edge costs and the Grid's
+	// EdgeCostFunc), and the Agent. It returns the cost the step should have for this search instead, or positive infinity
+	// (math.Inf(1)) if it can't be taken.
+	CostFunc func(from, to *Cell, cost float64, agent interface{}) float64
+}
+
+func (o *PathOptions) diagonals() bool {
+	return o != nil && o.Movement == MoveDiagonal
+}
+
+func (o *PathOptions) diagonalCost() float64 {
+	if o == nil || o.DiagonalCost == 0 {
+		return DefaultDiagonalCost
+	}
+	return o.DiagonalCost
+}
+
+func (o *PathOptions) cornerCutting() CornerRule {
+	if o == nil {
+		return NoCornerCutting
+	}
+	return o.CornerCutting
+}
+
+func (o *PathOptions) heuristic() Heuristic {
+	if o == nil {
+		return nil
+	}
+	return o.Heuristic
+}
+
+func (o *PathOptions) heuristicWeight() float64 {
+	if o == nil || o.HeuristicWeight == 0 {
+		return 1
+	}
+	return o.HeuristicWeight
+}
+
+func (o *PathOptions) maxNodes() int {
+	if o == nil {
+		return 0
+	}
+	return o.MaxNodes
+}
+
+func (o *PathOptions) maxCost() float64 {
+	if o == nil {
+		return 0
+	}
+	return o.MaxCost
+}
+
+func (o *PathOptions) tieBreak() TieBreak {
+	if o == nil {
+		return TieBreakNone
+	}
+	return o.TieBreak
+}
+
+func (o *PathOptions) algorithm() Algorithm {
+	if o == nil {
+		return AlgorithmAStar
+	}
+	return o.Algorithm
+}
+
+func (o *PathOptions) allowPartial() bool {
+	return o != nil && o.AllowPartial
+}
+
+func (o *PathOptions) passable(from, to *Cell) bool {
+	return o == nil || o.PassableFunc == nil || o.PassableFunc(from, to, o.Agent)
+}
+
+func (o *PathOptions) stepCost(from, to *Cell, cost float64) float64 {
+	if o == nil || o.CostFunc == nil {
+		return cost
+	}
+	return o.CostFunc(from, to, cost, o.Agent)
+}
+
+// custom returns whether the PathOptions have their own passability or cost functions.
+func (o *PathOptions) custom() bool {
+	return o != nil && (o.PassableFunc != nil || o.CostFunc != nil)
+}
+
+func (o *PathOptions) partialScore(cell, dest *Cell) float64 {
+	if o.PartialScore != nil {
+		return o.PartialScore(cell, dest)
+	} else if o.Heuristic != nil {
+		return o.Heuristic.Estimate(cell, dest)
+	}
+	return Euclidean.Estimate(cell, dest)
+}
@@ -0,0 +1,165 @@
+package paths
+
+import "time"
+
+// A Pathfinder finds Paths on a Grid. Unlike Grid.GetPathFromCells(), a Pathfinder holds on to the memory it uses while searching
+// and reuses it for the next search, so repeated searches allocate next to nothing (other than the Paths returned). A Pathfinder
+// isn't safe to use from multiple goroutines at once; create one per goroutine instead.
+type Pathfinder struct {
+	Grid *Grid
+
+	search indexSearch
+	graph  gridGraph
+}
+
+// bounds is a rectangle of Cells (inclusive) that a search is restricted to.
+type bounds struct {
+	minX, minY, maxX, maxY int
+}
+
+func (b *bounds) contains(cell *Cell) bool {
+	return cell.X >= b.minX && cell.Y >= b.minY && cell.X <= b.maxX && cell.Y <= b.maxY
+}
+
+// NewPathfinder returns a new Pathfinder that finds Paths on the Grid provided.
+func NewPathfinder(grid *Grid) *Pathfinder {
+	return &Pathfinder{Grid: grid}
+}
+
+// GetPath returns a Path, from the starting Cell to the destination Cell, as with Grid.GetPathFromCells(). The Path returned
+// doesn't share any memory with the Pathfinder, and so is safe to keep after searching again.
+func (pf *Pathfinder) GetPath(start, dest *Cell, options *PathOptions) *Path {
+	return pathFromResult(pf.Search(start, dest, options))
+}
+
+// Search searches for a Path from the starting Cell to the destination Cell, as with Grid.SearchFromCells().
+func (pf *Pathfinder) Search(start, dest *Cell, options *PathOptions) *PathResult {
+
+	began := time.Now()
+
+	if err := checkEnds(start, dest); err != nil && (err != ErrDestBlocked || !options.allowPartial()) {
+		return &PathResult{SearchStats: SearchStats{Err: err}}
+	}
+
+	pf.begin(start, dest, options)
+	for !pf.search.step() {
+	}
+
+	result := pf.result()
+	result.Duration = time.Since(began)
+	return result
+
+}
+
+// checkEnds returns an error if the start or destination of a search can't be used.
+func checkEnds(start, dest *Cell) error {
+	if start == nil || dest == nil {
+		return ErrOutOfBounds
+	} else if !start.Walkable {
+		return ErrStartBlocked
+	} else if !dest.Walkable {
+		return ErrDestBlocked
+	}
+	return nil
+}
+
+// getPathWithin returns a Path from the starting Cell to the destination Cell that doesn't leave the bounds provided, along
+// with the cost of moving along it (not counting the starting Cell). If there's no such Path, it returns nil.
+func (pf *Pathfinder) getPathWithin(start, dest *Cell, options *PathOptions, b *bounds) (*Path, float64) {
+
+	if !start.Walkable || !dest.Walkable {
+		return nil, 0
+	}
+
+	pf.begin(start, dest, options)
+	// Jumps can leave the bounds, so we stick to checking Cells one at a time.
+	pf.graph.jumping = false
+	pf.graph.bounds = b
+	for !pf.search.step() {
+	}
+	pf.graph.bounds = nil
+
+	if pf.search.found < 0 {
+		return nil, 0
+	}
+	return pf.path(pf.search.found), pf.search.records[pf.search.found].cost - start.Cost
+
+}
+
+// begin resets the Pathfinder's state to start a new search.
+func (pf *Pathfinder) begin(start, dest *Cell, options *PathOptions) {
+
+	g := &pf.graph
+	*g = gridGraph{
+		grid:       pf.Grid,
+		options:    options,
+		heuristic:  options.heuristic(),
+		dest:       dest,
+		neighbors:  g.neighbors,
+		directions: g.directions,
+	}
+	if options.algorithm() != AlgorithmAStar {
+		var cost float64
+		cost, g.jumping = g.canJump(options.algorithm())
+		if g.jumping && g.heuristic == nil {
+			g.octile = octileCost{cost, options.diagonalCost()}
+			g.heuristic = &g.octile
+		}
+	}
+
+	pf.search.begin(g, pf.Grid.Index(start.X, start.Y), pf.Grid.Index(dest.X, dest.Y), options)
+
+}
+
+// result returns the result of the current search.
+func (pf *Pathfinder) result() *PathResult {
+
+	result := &PathResult{SearchStats: SearchStats{NodesExpanded: pf.search.checked}}
+
+	slot, partial, err := pf.search.end()
+	if err != nil {
+		result.Err = err
+		return result
+	}
+
+	result.Path = pf.path(slot)
+	result.Cost = pf.search.records[slot].cost
+	result.Partial = partial
+	return result
+
+}
+
+// path returns the Path from the start of the current search to the Cell of the record in the slot provided.
+func (pf *Pathfinder) path(slot int32) *Path {
+
+	records := pf.search.records
+	cell := func(t int32) *Cell { return pf.Grid.GetIndex(int(records[t].node)) }
+
+	// When jumping, consecutive Cells can be several Cells apart (in a straight or diagonal line), so we count each step in
+	// between as well.
+	length := 1
+	for t := slot; records[t].parent >= 0; t = records[t].parent {
+		from, to := cell(records[t].parent), cell(t)
+		length += max(abs(to.X-from.X), abs(to.Y-from.Y))
+	}
+
+	// We've constructed our Path going from the start to the destination; we just have to loop through each record and go up,
+	// placing its Cell and its parents' (and any Cells between them) into the Path from the back.
+	path := &Path{Cells: make([]*Cell, length)}
+	for t := slot; t >= 0; t = records[t].parent {
+		to := cell(t)
+		length--
+		path.Cells[length] = to
+		if parent := records[t].parent; parent >= 0 {
+			from := cell(parent)
+			dx, dy := sign(from.X-to.X), sign(from.Y-to.Y)
+			for x, y := to.X+dx, to.Y+dy; x != from.X || y != from.Y; x, y = x+dx, y+dy {
+				length--
+				path.Cells[length] = pf.Grid.Get(x, y)
+			}
+		}
+	}
+
+	return path
+
+}
@@ -6,9 +6,9 @@ another.
 package paths
 
 import (
-	"container/heap"
 	"fmt"
 	"math"
+	"sync"
 )
 
 // A Cell represents a point on a Grid map. It has an X and Y value for the position, a Cost, which influences which Cells are
@@ -26,11 +26,47 @@ func (cell Cell) String() string {
 }
 
 // Grid represents a "map" composed of individual Cells at each point in the map.
-// Data is a 2D array of Cells.
+// Data is a 2D array of Cells, indexed by [y][x]. In Grids made with NewGrid() (or the other constructors), the Cells themselves
+// are stored together in one contiguous block, in rows (so the Cell at X, Y is at Index(x, y)); Data just points into it. Change
+// the fields of those Cells freely, but don't resize Data or replace its Cells, as the Grid won't see the new ones. A Grid put
+// together by hand from Data alone (i.e. &Grid{Data: cells}) has no such block, and reads its Cells (and size) from Data instead.
 // CellWidth and CellHeight indicate the size of Cells for Cell Position <-> World Position translation.
+//
+// A Grid (and its Cells) can be read from multiple goroutines at once, but not while it's being changed. If other goroutines
+// search the Grid (like a PathService does), change it only between calls to Lock() and Unlock().
 type Grid struct {
 	Data                  [][]*Cell
 	CellWidth, CellHeight int
+
+	// EdgeCostFunc, if set, is called for every step between neighboring Cells during pathfinding, with the Cell being stepped
+	// from, the one being stepped onto, and the cost the step would normally have (including any DiagonalCost or edge cost set
+	// with SetEdgeCost()). It returns the cost the step should have instead, or positive infinity (math.Inf(1)) if it can't be
+	// taken at all. This allows for costs that depend on direction, like walking uphill being harder than walking downhill. If the
+	// costs it returns change, call MarkChanged() with the Cells involved so that caches and planners notice.
+	EdgeCostFunc func(from, to *Cell, cost float64) float64
+
+	// cells is the contiguous block of Cells, which is nil if the Grid was put together by hand from Data.
+	cells         []Cell
+	width, height int
+	pathfinders   sync.Pool
+	jumpTable     *jumpTable
+	jumpMutex     sync.Mutex
+	mutex         sync.RWMutex
+
+	// uniform is whether every walkable Cell had the same Cost (uniformCost) as of uniformVersion (if uniformChecked is set).
+	uniform        bool
+	uniformCost    float64
+	uniformChecked bool
+	uniformVersion uint64
+
+	// version counts changes made to the Grid through its setters, and versions holds the version at which each Cell last
+	// changed (by index, Y * Width + X).
+	version     uint64
+	versions    []uint64
+	edgeCosts   map[[2]int]float64
+	dirty       []Rect
+	subscribers []subscriber
+	nextID      int
 }
 
 // NewGrid returns a new Grid of (gridWidth x gridHeight) size. cellWidth and cellHeight changes the size of each Cell in the Grid.
@@ -38,48 +74,62 @@ type Grid struct {
 // [16, 16] would be the world positon [32, 80]).
 func NewGrid(gridWidth, gridHeight, cellWidth, cellHeight int) *Grid {
 
-	m := &Grid{CellWidth: cellWidth, CellHeight: cellHeight}
+	m := &Grid{
+		CellWidth:  cellWidth,
+		CellHeight: cellHeight,
+		cells:      make([]Cell, gridWidth*gridHeight),
+		width:      gridWidth,
+		height:     gridHeight,
+	}
 
-	for y := 0; y < gridHeight; y++ {
-		m.Data = append(m.Data, []*Cell{})
-		for x := 0; x < gridWidth; x++ {
-			m.Data[y] = append(m.Data[y], &Cell{x, y, 1, true, ' '})
-		}
+	pointers := make([]*Cell, len(m.cells))
+	for i := range m.cells {
+		m.cells[i] = Cell{i % gridWidth, i / gridWidth, 1, true, ' '}
+		pointers[i] = &m.cells[i]
+	}
+
+	m.Data = make([][]*Cell, gridHeight)
+	for y := range m.Data {
+		m.Data[y] = pointers[y*gridWidth : (y+1)*gridWidth : (y+1)*gridWidth]
 	}
+
 	return m
+
 }
 
 // NewGridFromStringArrays creates a Grid map from a 1D array of strings. Each string becomes a row of Cells, each
 // with one rune as its character. cellWidth and cellHeight changes the size of each Cell in the Grid. This is used to
 // translate world position to Cell positions (i.e. the Cell position [2, 5] with a CellWidth and CellHeight of
-// [16, 16] would be the world positon [32, 80]).
+// [16, 16] would be the world positon [32, 80]). If the strings aren't all the same length, the Grid is as wide as the longest
+// one, and the shorter ones are filled out with Cells with a blank rune of ' '.
 func NewGridFromStringArrays(arrays []string, cellWidth, cellHeight int) *Grid {
 
-	m := &Grid{CellWidth: cellWidth, CellHeight: cellHeight}
-
-	for y := 0; y < len(arrays); y++ {
-		m.Data = append(m.Data, []*Cell{})
-		stringLine := []rune(arrays[y])
-		for x := 0; x < len(arrays[y]); x++ {
-			m.Data[y] = append(m.Data[y], &Cell{X: x, Y: y, Cost: 1, Walkable: true, Rune: stringLine[x]})
-		}
+	runes := make([][]rune, len(arrays))
+	for y := range arrays {
+		runes[y] = []rune(arrays[y])
 	}
 
-	return m
+	return NewGridFromRuneArrays(runes, cellWidth, cellHeight)
 
 }
 
 // NewGridFromRuneArrays creates a Grid map from a 2D array of runes. Each individual Rune becomes a Cell in the resulting
 // Grid. cellWidth and cellHeight changes the size of each Cell in the Grid. This is used to translate world position to Cell
 // positions (i.e. the Cell position [2, 5] with a CellWidth and CellHeight of [16, 16] would be the world positon [32, 80]).
+// If the arrays aren't all the same length, the Grid is as wide as the longest one, and the shorter ones are filled out with
+// Cells with a blank rune of ' '.
 func NewGridFromRuneArrays(arrays [][]rune, cellWidth, cellHeight int) *Grid {
 
-	m := &Grid{CellWidth: cellWidth, CellHeight: cellHeight}
+	width := 0
+	for _, row := range arrays {
+		width = max(width, len(row))
+	}
+
+	m := NewGrid(width, len(arrays), cellWidth, cellHeight)
 
-	for y := 0; y < len(arrays); y++ {
-		m.Data = append(m.Data, []*Cell{})
-		for x := 0; x < len(arrays[y]); x++ {
-			m.Data[y] = append(m.Data[y], &Cell{X: x, Y: y, Cost: 1, Walkable: true, Rune: arrays[y][x]})
+	for y, row := range arrays {
+		for x, r := range row {
+			m.cells[y*width+x].Rune = r
 		}
 	}
 
@@ -101,129 +151,184 @@ func (m *Grid) DataToString() string {
 
 // Get returns a pointer to the Cell in the x and y position provided.
 func (m *Grid) Get(x, y int) *Cell {
-	if x < 0 || y < 0 || x >= m.Width() || y >= m.Height() {
+	if m.cells == nil {
+		if x < 0 || y < 0 || y >= len(m.Data) || x >= len(m.Data[y]) || x >= m.Width() {
+			return nil
+		}
+		return m.Data[y][x]
+	}
+	if x < 0 || y < 0 || x >= m.width || y >= m.height {
 		return nil
 	}
-	return m.Data[y][x]
+	return &m.cells[y*m.width+x]
+}
+
+// Index returns the index of the grid position provided (Y * Width + X), which is where its Cell is stored in the Grid. If the
+// position is outside of the Grid, Index returns -1.
+func (m *Grid) Index(x, y int) int {
+	w := m.Width()
+	if x < 0 || y < 0 || x >= w || y >= m.Height() {
+		return -1
+	}
+	return y*w + x
+}
+
+// Position returns the grid position of the index provided (the opposite of Index()).
+func (m *Grid) Position(index int) (int, int) {
+	w := m.Width()
+	return index % w, index / w
+}
+
+// GetIndex returns a pointer to the Cell at the index provided, or nil if the index is outside of the Grid.
+func (m *Grid) GetIndex(index int) *Cell {
+	if m.cells == nil {
+		if index < 0 || index >= m.Nodes() {
+			return nil
+		}
+		return m.Get(m.Position(index))
+	}
+	if index < 0 || index >= len(m.cells) {
+		return nil
+	}
+	return &m.cells[index]
 }
 
 // Height returns the height of the Grid map.
 func (m *Grid) Height() int {
-	return len(m.Data)
+	if m.cells == nil {
+		return len(m.Data)
+	}
+	return m.height
 }
 
 // Width returns the width of the Grid map.
 func (m *Grid) Width() int {
-	return len(m.Data[0])
+	if m.cells == nil {
+		if len(m.Data) == 0 {
+			return 0
+		}
+		return len(m.Data[0])
+	}
+	return m.width
 }
 
 // CellsByRune returns a slice of pointers to Cells that all have the character provided.
 func (m *Grid) CellsByRune(char rune) []*Cell {
-
-	cells := make([]*Cell, 0)
-
-	for y := 0; y < m.Height(); y++ {
-		for x := 0; x < m.Width(); x++ {
-			c := m.Get(x, y)
-			if c.Rune == char {
-				cells = append(cells, c)
-			}
-		}
-	}
-
-	return cells
-
+	return m.cellsWhere(func(cell *Cell) bool { return cell.Rune == char })
 }
 
 // AllCells returns a single slice of pointers to all Cells contained in the Grid's 2D Data array.
 func (m *Grid) AllCells() []*Cell {
-
-	cells := make([]*Cell, 0)
-
-	for y := 0; y < m.Height(); y++ {
-		for x := 0; x < m.Width(); x++ {
-			cells = append(cells, m.Get(x, y))
-		}
-	}
-
-	return cells
-
+	return m.cellsWhere(nil)
 }
 
 // CellsByCost returns a slice of pointers to Cells that all have the Cost value provided.
 func (m *Grid) CellsByCost(cost float64) []*Cell {
-
-	cells := make([]*Cell, 0)
-
-	for y := 0; y < m.Height(); y++ {
-
-		for x := 0; x < m.Width(); x++ {
-
-			c := m.Get(x, y)
-			if c.Cost == cost {
-				cells = append(cells, c)
-			}
-
-		}
-
-	}
-
-	return cells
-
+	return m.cellsWhere(func(cell *Cell) bool { return cell.Cost == cost })
 }
 
 // CellsByWalkable returns a slice of pointers to Cells that all have the Cost value provided.
 func (m *Grid) CellsByWalkable(walkable bool) []*Cell {
+	return m.cellsWhere(func(cell *Cell) bool { return cell.Walkable == walkable })
+}
 
-	cells := make([]*Cell, 0)
-
-	for y := 0; y < m.Height(); y++ {
-
-		for x := 0; x < m.Width(); x++ {
+// cellsWhere returns a slice of pointers to the Cells for which match returns true, or all of them if match is nil.
+func (m *Grid) cellsWhere(match func(cell *Cell) bool) []*Cell {
 
-			c := m.Get(x, y)
-			if c.Walkable == walkable {
-				cells = append(cells, c)
-			}
+	cells := make([]*Cell, 0)
 
+	for i, n := 0, m.Nodes(); i < n; i++ {
+		if cell := m.GetIndex(i); cell != nil && (match == nil || match(cell)) {
+			cells = append(cells, cell)
 		}
-
 	}
 
 	return cells
 
 }
 
-// SetWalkable sets walkability across all cells in the Grid with the specified rune.
+// SetWalkable sets walkability across all cells in the Grid with the specified rune. The change is recorded as one change to
+// the Grid (see Subscribe()).
 func (m *Grid) SetWalkable(char rune, walkable bool) {
 
+	changed := []*Cell{}
+
 	for y := 0; y < m.Height(); y++ {
 
 		for x := 0; x < m.Width(); x++ {
 			cell := m.Get(x, y)
-			if cell.Rune == char {
+			if cell.Rune == char && cell.Walkable != walkable {
 				cell.Walkable = walkable
+				changed = append(changed, cell)
 			}
 		}
 
 	}
 
+	m.MarkChanged(changed...)
+
 }
 
-// SetCost sets the movement cost across all cells in the Grid with the specified rune.
+// SetCost sets the movement cost across all cells in the Grid with the specified rune. The change is recorded as one change to
+// the Grid (see Subscribe()).
 func (m *Grid) SetCost(char rune, cost float64) {
 
+	changed := []*Cell{}
+
 	for y := 0; y < m.Height(); y++ {
 
 		for x := 0; x < m.Width(); x++ {
 			cell := m.Get(x, y)
-			if cell.Rune == char {
+			if cell.Rune == char && cell.Cost != cost {
 				cell.Cost = cost
+				changed = append(changed, cell)
 			}
 		}
 
 	}
 
+	m.MarkChanged(changed...)
+
+}
+
+// SetCellWalkable sets the walkability of the Cell provided. Unlike setting Cell.Walkable directly, this records the change,
+// so that anything relying on the Grid (like a PathCache or a watching FlowField) knows about it.
+func (m *Grid) SetCellWalkable(cell *Cell, walkable bool) {
+	if cell.Walkable != walkable {
+		cell.Walkable = walkable
+		m.MarkChanged(cell)
+	}
+}
+
+// SetCellCost sets the movement cost of the Cell provided. Unlike setting Cell.Cost directly, this records the change, so that
+// anything relying on the Grid (like a PathCache or a watching FlowField) knows about it.
+func (m *Grid) SetCellCost(cell *Cell, cost float64) {
+	if cell.Cost != cost {
+		cell.Cost = cost
+		m.MarkChanged(cell)
+	}
+}
+
+// Lock locks the Grid for changes, waiting for any searches reading it from other goroutines (i.e. by a PathService or
+// PathCache) to finish first. Searches started afterwards wait until Unlock() is called.
+func (m *Grid) Lock() {
+	m.mutex.Lock()
+}
+
+// Unlock unlocks the Grid after changing it, allowing other goroutines to search it again.
+func (m *Grid) Unlock() {
+	m.mutex.Unlock()
+}
+
+// RLock locks the Grid for reading, so that it can be searched or read from safely while other goroutines may change it.
+// Multiple goroutines can hold a read lock at once. Calls to RLock() shouldn't be nested.
+func (m *Grid) RLock() {
+	m.mutex.RLock()
+}
+
+// RUnlock undoes a call to RLock().
+func (m *Grid) RUnlock() {
+	m.mutex.RUnlock()
 }
 
 // GridToWorld converts from a grid position to world position, multiplying the value by the CellWidth and CellHeight of the Grid.
@@ -240,182 +345,195 @@ func (m *Grid) WorldToGrid(x, y float64) (int, int) {
 	return tx, ty
 }
 
-// GetPathFromCells returns a Path, from the starting Cell to the destination Cell. diagonals controls whether moving diagonally
-// is acceptable when creating the Path. wallsBlockDiagonals indicates whether to allow diagonal movement "through" walls that are
-// positioned diagonally.
-func (m *Grid) GetPathFromCells(start, dest *Cell, diagonals, wallsBlockDiagonals bool) *Path {
-
-	openNodes := minHeap{}
-	heap.Push(&openNodes, &Node{Cell: dest, Cost: dest.Cost})
-
-	checkedNodes := make([]*Cell, 0)
-
-	hasBeenAdded := func(cell *Cell) bool {
-
-		for _, c := range checkedNodes {
-			if cell == c {
-				return true
-			}
-		}
-		return false
-
-	}
+// GetPathFromCells returns a Path, from the starting Cell to the destination Cell. options controls how the Path is found
+// (i.e. whether moving diagonally is acceptable, or whether to guide the search with a Heuristic); a nil options finds the
+// cheapest Path moving orthogonally. GetPathFromCells returns nil if either Cell isn't walkable, and an empty Path if no Path
+// could be found; use SearchFromCells() to find out why. GetPathFromCells reuses Pathfinders internally, so it's safe to call
+// from multiple goroutines as long as the Grid isn't being modified.
+func (m *Grid) GetPathFromCells(start, dest *Cell, options *PathOptions) *Path {
+	return pathFromResult(m.SearchFromCells(start, dest, options))
+}
 
-	path := &Path{}
+// SearchFromCells searches for a Path from the starting Cell to the destination Cell, as with GetPathFromCells(), and returns
+// the result, including the reason no Path was found (if so) and statistics about the search.
+func (m *Grid) SearchFromCells(start, dest *Cell, options *PathOptions) *PathResult {
 
-	if !start.Walkable || !dest.Walkable {
-		return nil
+	pf, ok := m.pathfinders.Get().(*Pathfinder)
+	if !ok {
+		pf = NewPathfinder(m)
 	}
+	result := pf.Search(start, dest, options)
+	m.pathfinders.Put(pf)
+	return result
 
-	for {
+}
 
-		// If the list of openNodes (nodes to check) is at 0, then we've checked all Nodes, and so the function can quit.
-		if len(openNodes) == 0 {
-			break
-		}
+// neighbor is a Cell next to another, along with the cost of stepping onto it.
+type neighbor struct {
+	Cell *Cell
+	Cost float64
+}
 
-		node := heap.Pop(&openNodes).(*Node)
+// appendNeighbors appends each walkable neighbor of the given Cell that can be stepped onto from it to the slice provided, along
+// with the cost of stepping onto it, and returns the result.
+func (m *Grid) appendNeighbors(neighbors []neighbor, cell *Cell, options *PathOptions) []neighbor {
+	return m.appendAdjacent(neighbors, cell, options, false)
+}
 
-		// If we've reached the start, then we've constructed our Path going from the destination to the start; we just have
-		// to loop through each Node and go up, adding it and its parents recursively to the path.
-		if node.Cell == start {
+// appendPredecessors appends each walkable neighbor of the given Cell that can step onto it to the slice provided, along with
+// the cost of stepping from the neighbor onto the Cell, and returns the result.
+func (m *Grid) appendPredecessors(neighbors []neighbor, cell *Cell, options *PathOptions) []neighbor {
+	return m.appendAdjacent(neighbors, cell, options, true)
+}
 
-			var t = node
-			for true {
-				path.Cells = append(path.Cells, t.Cell)
-				t = t.Parent
-				if t == nil {
-					break
+// appendAffected appends the Cells whose steps are affected by changes to the walkability or Cost of the Cells provided to the
+// slice given, and returns the result. A Cell changing affects moving onto it, as well as diagonal moves past its corners, so
+// its neighbors are affected as well. Cells next to more than one of the Cells provided are appended more than once.
+func (m *Grid) appendAffected(affected []*Cell, cells []*Cell) []*Cell {
+	for _, cell := range cells {
+		for y := cell.Y - 1; y <= cell.Y+1; y++ {
+			for x := cell.X - 1; x <= cell.X+1; x++ {
+				if c := m.Get(x, y); c != nil {
+					affected = append(affected, c)
 				}
 			}
-
-			break
 		}
+	}
+	return affected
+}
 
-		// Otherwise, we add the current node's neighbors to the list of cells to check, and list of cells that have already been
-		// checked (so we don't get nodes being checked multiple times).
-		if node.Cell.X > 0 {
-			c := m.Get(node.Cell.X-1, node.Cell.Y)
-			n := &Node{c, node, c.Cost + node.Cost}
-			if n.Cell.Walkable && !hasBeenAdded(n.Cell) {
-				heap.Push(&openNodes, n)
-				checkedNodes = append(checkedNodes, n.Cell)
-			}
-		}
-		if node.Cell.X < m.Width()-1 {
-			c := m.Get(node.Cell.X+1, node.Cell.Y)
-			n := &Node{c, node, c.Cost + node.Cost}
-			if n.Cell.Walkable && !hasBeenAdded(n.Cell) {
-				heap.Push(&openNodes, n)
-				checkedNodes = append(checkedNodes, n.Cell)
-			}
+// appendAdjacent appends the neighbors of the given Cell that can be stepped onto from it (or, if reverse is true, that can step
+// onto it).
+func (m *Grid) appendAdjacent(neighbors []neighbor, cell *Cell, options *PathOptions, reverse bool) []neighbor {
+
+	up := m.Get(cell.X, cell.Y-1)
+	down := m.Get(cell.X, cell.Y+1)
+	left := m.Get(cell.X-1, cell.Y)
+	right := m.Get(cell.X+1, cell.Y)
+
+	for _, c := range [4]*Cell{left, right, up, down} {
+		if c != nil && c.Walkable {
+			neighbors = m.appendStep(neighbors, cell, c, options, reverse)
 		}
+	}
 
-		if node.Cell.Y > 0 {
-			c := m.Get(node.Cell.X, node.Cell.Y-1)
-			n := &Node{c, node, c.Cost + node.Cost}
-			if n.Cell.Walkable && !hasBeenAdded(n.Cell) {
-				heap.Push(&openNodes, n)
-				checkedNodes = append(checkedNodes, n.Cell)
+	// Do the same thing for diagonals.
+	if options.diagonals() {
+
+		for _, d := range [4]struct {
+			x, y int
+			a, b *Cell
+		}{
+			{cell.X - 1, cell.Y - 1, left, up},
+			{cell.X + 1, cell.Y - 1, right, up},
+			{cell.X - 1, cell.Y + 1, left, down},
+			{cell.X + 1, cell.Y + 1, right, down},
+		} {
+
+			c := m.Get(d.x, d.y)
+			if c == nil || !c.Walkable {
+				continue
 			}
-		}
-		if node.Cell.Y < m.Height()-1 {
-			c := m.Get(node.Cell.X, node.Cell.Y+1)
-			n := &Node{c, node, c.Cost + node.Cost}
-			if n.Cell.Walkable && !hasBeenAdded(n.Cell) {
-				heap.Push(&openNodes, n)
-				checkedNodes = append(checkedNodes, n.Cell)
+
+			// The Cells beside the step are checked as if they were being stepped onto from wherever the step starts.
+			from := cell
+			if reverse {
+				from = c
 			}
-		}
 
-		// Do the same thing for diagonals.
-		if diagonals {
+			if m.cornerBlocked(from, d.a, d.b, options) {
+				continue
+			}
 
-			diagonalCost := .414 // Diagonal movement is slightly slower, so we should prioritize straightaways if possible
+			neighbors = m.appendStep(neighbors, cell, c, options, reverse)
 
-			up := false
-			upNeighbor := m.Get(node.Cell.X, node.Cell.Y-1)
-			if upNeighbor != nil && upNeighbor.Walkable {
-				up = true
-			}
+		}
 
-			down := false
-			downNeighbor := m.Get(node.Cell.X, node.Cell.Y+1)
-			if downNeighbor != nil && downNeighbor.Walkable {
-				down = true
-			}
+	}
 
-			left := false
-			leftNeighbor := m.Get(node.Cell.X-1, node.Cell.Y)
-			if leftNeighbor != nil && leftNeighbor.Walkable {
-				up = true
-			}
+	return neighbors
 
-			right := false
-			rightNeighbor := m.Get(node.Cell.X+1, node.Cell.Y)
-			if rightNeighbor != nil && rightNeighbor.Walkable {
-				right = true
-			}
+}
 
+// cornerBlocked returns whether a diagonal step from the Cell provided is blocked by the Cells on either side of it (either of
+// which can be nil, if it's outside of the Grid), according to the options' CornerCutting.
+func (m *Grid) cornerBlocked(from, a, b *Cell, options *PathOptions) bool {
+	aOpen := a != nil && a.Walkable && options.passable(from, a)
+	bOpen := b != nil && b.Walkable && options.passable(from, b)
+	switch options.cornerCutting() {
+	case NoCornerCutting:
+		return !aOpen || !bOpen
+	case CornerCuttingOneWall:
+		return !aOpen && !bOpen
+	}
+	return false
+}
 
-			if node.Cell.X > 0 && node.Cell.Y > 0 {
-				c := m.Get(node.Cell.X-1, node.Cell.Y-1)
-				n := &Node{c, node, c.Cost + node.Cost + diagonalCost}
-				if n.Cell.Walkable && !hasBeenAdded(n.Cell) && (!wallsBlockDiagonals || (left && up)) {
-					heap.Push(&openNodes, n)
-					checkedNodes = append(checkedNodes, n.Cell)
-				}
-			}
+// moveCost returns the cost of moving from one Cell onto a neighboring one, orthogonally or diagonally, as a search with the
+// PathOptions provided would. If either Cell isn't walkable, a diagonal move is blocked by the Cells beside it, or the step
+// can't be taken, it returns positive infinity.
+func (m *Grid) moveCost(from, to *Cell, options *PathOptions) float64 {
+	if !from.Walkable || !to.Walkable {
+		return math.Inf(1)
+	}
+	if from.X != to.X && from.Y != to.Y && m.cornerBlocked(from, m.Get(to.X, from.Y), m.Get(from.X, to.Y), options) {
+		return math.Inf(1)
+	}
+	return m.stepCost(from, to, options)
+}
 
-			if node.Cell.X < m.Width()-1 && node.Cell.Y > 0 {
-				c := m.Get(node.Cell.X+1, node.Cell.Y-1)
-				n := &Node{c, node, c.Cost + node.Cost + diagonalCost}
-				if n.Cell.Walkable && !hasBeenAdded(n.Cell) && (!wallsBlockDiagonals || (right && up)) {
-					heap.Push(&openNodes, n)
-					checkedNodes = append(checkedNodes, n.Cell)
-				}
-			}
+// appendStep appends the step between the Cell and its neighbor (in whichever direction) if it can be taken.
+func (m *Grid) appendStep(neighbors []neighbor, cell, n *Cell, options *PathOptions, reverse bool) []neighbor {
 
-			if node.Cell.X > 0 && node.Cell.Y < m.Height()-1 {
-				c := m.Get(node.Cell.X-1, node.Cell.Y+1)
-				n := &Node{c, node, c.Cost + node.Cost + diagonalCost}
-				if n.Cell.Walkable && !hasBeenAdded(n.Cell) && (!wallsBlockDiagonals || (left && down)) {
-					heap.Push(&openNodes, n)
-					checkedNodes = append(checkedNodes, n.Cell)
-				}
-			}
+	var cost float64
+	if reverse {
+		cost = m.stepCost(n, cell, options)
+	} else {
+		cost = m.stepCost(cell, n, options)
+	}
 
-			if node.Cell.X < m.Width()-1 && node.Cell.Y < m.Height()-1 {
-				c := m.Get(node.Cell.X+1, node.Cell.Y+1)
-				n := &Node{c, node, c.Cost + node.Cost + diagonalCost}
-				if n.Cell.Walkable && !hasBeenAdded(n.Cell) && (!wallsBlockDiagonals || (right && down)) {
-					heap.Push(&openNodes, n)
-					checkedNodes = append(checkedNodes, n.Cell)
-				}
-			}
+	if math.IsInf(cost, 1) {
+		return neighbors
+	}
+	return append(neighbors, neighbor{n, cost})
 
-		}
+}
 
+// stepCost returns the cost of stepping from one Cell onto a neighboring one, taking any edge costs and the PathOptions'
+// PassableFunc and CostFunc into account. If the step can't be taken, it returns positive infinity.
+func (m *Grid) stepCost(from, to *Cell, options *PathOptions) float64 {
+	cost := to.Cost
+	if from.X != to.X && from.Y != to.Y {
+		cost += options.diagonalCost()
 	}
+	return m.adjustStep(from, to, cost, options)
+}
 
-	return path
+// adjustStep returns the cost of stepping from one Cell onto a neighboring one, given the cost the step would normally have,
+// after applying any edge costs and the PathOptions' PassableFunc and CostFunc. If the step can't be taken, it returns positive
+// infinity. Straight lines crossing the Grid use it to cost each step along them without any DiagonalCost.
+func (m *Grid) adjustStep(from, to *Cell, cost float64, options *PathOptions) float64 {
+	if !options.passable(from, to) {
+		return math.Inf(1)
+	}
+	return options.stepCost(from, to, m.edgeCost(from, to, cost))
+}
 
+// GetPath returns a Path, from the starting world X and Y position to the ending X and Y position. options controls how the
+// Path is found, as in GetPathFromCells. This is essentially just a smoother way to get a Path from GetPathFromCells(). If
+// either position is outside of the Grid, GetPath returns nil.
+func (m *Grid) GetPath(startX, startY, endX, endY float64, options *PathOptions) *Path {
+	return pathFromResult(m.Search(startX, startY, endX, endY, options))
 }
 
-// GetPath returns a Path, from the starting world X and Y position to the ending X and Y position. diagonals controls whether
-// moving diagonally is acceptable when creating the Path. wallsBlockDiagonals indicates whether to allow diagonal movement "through" walls
-// that are positioned diagonally. This is essentially just a smoother way to get a Path from GetPathFromCells().
-func (m *Grid) GetPath(startX, startY, endX, endY float64, diagonals bool, wallsBlockDiagonals bool) *Path {
+// Search searches for a Path from the starting world X and Y position to the ending X and Y position, as with GetPath(), and
+// returns the result. If either position is outside of the Grid, the result's Err is ErrOutOfBounds.
+func (m *Grid) Search(startX, startY, endX, endY float64, options *PathOptions) *PathResult {
 
 	sx, sy := m.WorldToGrid(startX, startY)
-	sc := m.Get(sx, sy)
 	ex, ey := m.WorldToGrid(endX, endY)
-	ec := m.Get(ex, ey)
+	return m.SearchFromCells(m.Get(sx, sy), m.Get(ex, ey), options)
 
-	if sc != nil && ec != nil {
-		return m.GetPathFromCells(sc, ec, diagonals, wallsBlockDiagonals)
-	}
-	return nil
 }
 
 // DataAsStringArray returns a 2D array of runes for each Cell in the Grid. The first axis is the Y axis.
@@ -590,25 +708,11 @@ func (p *Path) AtEnd() bool {
 
 // Node represents the node a path, it contains the cell it represents.
 // Also contains other information such as the parent and the cost.
+//
+// Deprecated: Node was used by the search's heap, which no longer exists. Nothing in paths uses or returns it anymore; it's
+// kept so that code referring to it still builds.
 type Node struct {
 	Cell   *Cell
 	Parent *Node
 	Cost   float64
 }
-
-type minHeap []*Node
-
-func (mH minHeap) Len() int           { return len(mH) }
-func (mH minHeap) Less(i, j int) bool { return mH[i].Cost < mH[j].Cost }
-func (mH minHeap) Swap(i, j int)      { mH[i], mH[j] = mH[j], mH[i] }
-func (mH *minHeap) Pop() interface{} {
-	old := *mH
-	n := len(old)
-	x := old[n-1]
-	*mH = old[0 : n-1]
-	return x
-}
-
-func (mH *minHeap) Push(x interface{}) {
-	*mH = append(*mH, x.(*Node))
-}
@@ -0,0 +1,410 @@
+package paths
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"testing"
+)
+
+// randomGrid returns a Grid of the size provided with roughly the fraction of Cells given made unwalkable. If costs is true,
+// the walkable Cells are given random Costs from 1 to 4.
+func randomGrid(rng *rand.Rand, width, height int, walls float64, costs bool) *Grid {
+	m := NewGrid(width, height, 16, 16)
+	for _, cell := range m.AllCells() {
+		cell.Walkable = rng.Float64() >= walls
+		if costs {
+			cell.Cost = float64(1 + rng.Intn(4))
+		}
+	}
+	return m
+}
+
+// randomCell returns a random Cell of the Grid provided.
+func randomCell(rng *rand.Rand, m *Grid) *Cell {
+	return m.Get(rng.Intn(m.Width()), rng.Intn(m.Height()))
+}
+
+// A gridEdit sets the walkability and Cost of the Cells within a region of a Grid.
+type gridEdit struct {
+	region   Rect
+	walkable bool
+	cost     float64
+}
+
+// randomEdit returns an edit of a random region of the Grid provided, up to size Cells wide and tall. Two thirds of the edits
+// leave the Cells walkable.
+func randomEdit(rng *rand.Rand, m *Grid, size int) gridEdit {
+	region := Rect{X: rng.Intn(m.Width()), Y: rng.Intn(m.Height()), W: 1 + rng.Intn(size), H: 1 + rng.Intn(size)}
+	return gridEdit{region, rng.Intn(3) > 0, float64(1 + rng.Intn(4))}
+}
+
+// An updater keeps up with changes to a Grid, either through Watch() or by being told about them with UpdateCells().
+type updater interface {
+	Watch()
+	Unwatch()
+	UpdateCells(cells ...*Cell)
+}
+
+// testUpdates checks that an updater keeps up with the edits provided. If watching is true, it watches the Grid while the edits
+// are made through the Grid's setters; otherwise, the Cells are changed directly and passed to UpdateCells(). After each edit,
+// check is called with the updater and a new one made by build.
+func testUpdates(m *Grid, watching bool, edits []gridEdit, build func() updater, check func(got, want updater)) {
+
+	u := build()
+	if watching {
+		u.Watch()
+		defer u.Unwatch()
+	}
+
+	for _, edit := range edits {
+
+		if watching {
+			m.SetRegionWalkable(edit.region, edit.walkable)
+			m.SetRegionCost(edit.region, edit.cost)
+		} else {
+			cells := m.regionCells(edit.region)
+			for _, cell := range cells {
+				cell.Walkable, cell.Cost = edit.walkable, edit.cost
+			}
+			u.UpdateCells(cells...)
+		}
+
+		check(u, build())
+
+	}
+
+}
+
+// sameCost returns whether two search results found Paths of the same cost (or both found none).
+func sameCost(a, b *PathResult) bool {
+	if a.Err != b.Err || (a.Path == nil) != (b.Path == nil) {
+		return false
+	}
+	return math.Abs(a.Cost-b.Cost) < 1e-9
+}
+
+// benchmarkGrid returns a 1000x1000 Grid with a fifth of its Cells unwalkable, other than the corners.
+func benchmarkGrid() *Grid {
+	m := randomGrid(rand.New(rand.NewSource(1)), 1000, 1000, 0.2, false)
+	m.Get(0, 0).Walkable = true
+	m.Get(999, 999).Walkable = true
+	return m
+}
+
+func TestPathfinderReuse(t *testing.T) {
+
+	rng := rand.New(rand.NewSource(3))
+
+	for i := 0; i < 20; i++ {
+
+		m := randomGrid(rng, 40, 30, 0.3, i%2 == 0)
+		reused := NewPathfinder(m)
+
+		for j := 0; j < 50; j++ {
+
+			start, dest := randomCell(rng, m), randomCell(rng, m)
+			options := &PathOptions{Movement: Movement(rng.Intn(2)), Heuristic: Manhattan}
+			if options.Movement == MoveDiagonal {
+				options.Heuristic = Octile
+			}
+
+			want := NewPathfinder(m).Search(start, dest, options)
+			if got := reused.Search(start, dest, options); !sameCost(got, want) {
+				t.Fatalf("reused Pathfinder found %v (cost %f), but a fresh one found %v (cost %f)", got.Err, got.Cost, want.Err, want.Cost)
+			}
+
+		}
+
+	}
+
+}
+
+func TestPathfinderStaleSlots(t *testing.T) {
+
+	rng := rand.New(rand.NewSource(4))
+	small := randomGrid(rng, 12, 9, 0.2, true)
+	large := randomGrid(rng, 30, 30, 0, true)
+	pf := NewPathfinder(large)
+
+	// The first search reaches nearly every Cell, leaving slots behind that point at records of the searches that follow. Then the
+	// Pathfinder is moved between Grids of different sizes, so that its slots are left over from another Grid entirely.
+	pf.Search(large.Get(0, 0), large.Get(29, 29), nil)
+
+	for i := 0; i < 30; i++ {
+		m := small
+		if i%3 == 2 {
+			m = large
+		}
+		pf.Grid = m
+		start, dest := randomCell(rng, m), randomCell(rng, m)
+		want := NewPathfinder(m).Search(start, dest, nil)
+		if got := pf.Search(start, dest, nil); !sameCost(got, want) {
+			t.Fatalf("search %d found %v (cost %f), but a fresh Pathfinder found %v (cost %f)", i, got.Err, got.Cost, want.Err, want.Cost)
+		}
+	}
+
+}
+
+func TestSearchStep(t *testing.T) {
+
+	rng := rand.New(rand.NewSource(5))
+	m := randomGrid(rng, 20, 20, 0.2, true)
+
+	for _, cells := range []int{-3, 0, 1, 7} {
+
+		start, dest := randomCell(rng, m), randomCell(rng, m)
+		want := m.SearchFromCells(start, dest, nil)
+		search := m.NewSearch(start, dest, nil)
+
+		// Even without a positive budget, every Step() should get the Search closer to finishing.
+		steps := 0
+		for !search.Step(cells) {
+			if steps++; steps > m.Width()*m.Height()*4 {
+				t.Fatalf("Search stepping by %d Cells never finished", cells)
+			}
+		}
+
+		if got := search.Result(); !sameCost(got, want) {
+			t.Fatalf("Search stepping by %d Cells found %v (cost %f), but searching at once found %v (cost %f)", cells, got.Err, got.Cost, want.Err, want.Cost)
+		}
+
+	}
+
+}
+
+func TestSetEdgeCostNeighbors(t *testing.T) {
+
+	m := NewGrid(5, 5, 16, 16)
+	other := NewGrid(5, 5, 16, 16)
+
+	// Only steps between neighboring Cells of the same Grid can be given a cost.
+	m.SetEdgeCost(m.Get(0, 0), m.Get(2, 0), 3)
+	m.SetEdgeCost(m.Get(1, 1), m.Get(1, 1), 3)
+	m.SetEdgeCost(m.Get(0, 0), other.Get(1, 0), 3)
+	m.SetEdgePassable(other.Get(1, 1), m.Get(2, 2), false)
+	if m.edgeCosts != nil {
+		t.Fatalf("Grid recorded edge costs for Cells that aren't its neighbors: %v", m.edgeCosts)
+	}
+
+	m.SetEdgeCost(m.Get(1, 1), m.Get(2, 2), 3)
+	other.SetEdgePassable(m.Get(1, 1), m.Get(2, 2), true)
+	if cost := m.EdgeCost(m.Get(1, 1), m.Get(2, 2), nil); cost != 3 {
+		t.Fatalf("edge cost between diagonal neighbors should be 3, not %f", cost)
+	}
+
+}
+
+// wallGrid returns a Grid split in two by an unwalkable wall down the middle.
+func wallGrid() *Grid {
+	m := NewGridFromStringArrays([]string{
+		"    x    ",
+		"    x    ",
+		"    x    ",
+		"    x    ",
+		"    x    ",
+	}, 16, 16)
+	m.SetWalkable('x', false)
+	return m
+}
+
+func TestAllowPartial(t *testing.T) {
+
+	m := wallGrid()
+	start, dest := m.Get(0, 1), m.Get(8, 2)
+
+	if result := m.SearchFromCells(start, dest, &PathOptions{Movement: MoveDiagonal}); result.Err != ErrNoPath {
+		t.Fatalf("search through a wall returned %v, not ErrNoPath", result.Err)
+	}
+
+	// The closest Cell to the destination is next to the wall, whether the destination's beyond it or in it.
+	options := &PathOptions{Movement: MoveDiagonal, AllowPartial: true}
+	for _, dest := range []*Cell{dest, m.Get(4, 2)} {
+		result := m.SearchFromCells(start, dest, options)
+		if result.Err != nil || !result.Partial {
+			t.Fatalf("partial search to %s returned %v (partial: %t)", dest, result.Err, result.Partial)
+		}
+		if last := result.Path.Cells[len(result.Path.Cells)-1]; last != m.Get(3, 2) {
+			t.Fatalf("partial search to %s should lead to %s, not %s", dest, m.Get(3, 2), last)
+		}
+		if cost, bad := pathCost(m, result.Path, options); bad != "" || math.Abs(cost-result.Cost) > 1e-9 {
+			t.Fatalf("partial search to %s returned a Path costing %f (%s), but reported %f", dest, cost, bad, result.Cost)
+		}
+	}
+
+	// PartialScore decides which Cell is closest, here preferring the top-left corner over getting anywhere near the
+	// destination.
+	corner := func(cell, dest *Cell) float64 {
+		return float64(cell.X + cell.Y)
+	}
+	options = &PathOptions{Movement: MoveDiagonal, AllowPartial: true, PartialScore: corner}
+	if result := m.SearchFromCells(start, dest, options); result.Err != nil || result.Path.Cells[len(result.Path.Cells)-1] != m.Get(0, 0) {
+		t.Fatalf("partial search with a PartialScore should lead to %s, not %v", m.Get(0, 0), result.Path)
+	}
+
+	// The Graph from Grid.Graph() scores nodes with its own PathOptions, too.
+	graph := m.Graph(&PathOptions{Movement: MoveDiagonal, PartialScore: corner})
+	if result := SearchGraph(graph, m.Index(start.X, start.Y), m.Index(dest.X, dest.Y), &PathOptions{AllowPartial: true}); result.Err != nil || !result.Partial || result.Nodes[len(result.Nodes)-1] != m.Index(0, 0) {
+		t.Fatalf("partial Graph search should lead to %s, not %v (%v)", m.Get(0, 0), result.Nodes, result.Err)
+	}
+
+}
+
+func TestAllowPartialJPS(t *testing.T) {
+
+	m := wallGrid()
+	start, dest := m.Get(0, 1), m.Get(8, 2)
+	closest := m.SearchFromCells(start, dest, &PathOptions{Movement: MoveDiagonal, AllowPartial: true})
+
+	for _, algorithm := range []Algorithm{AlgorithmJPS, AlgorithmJPSPlus} {
+
+		// JPS only checks the jump points it stops at, so the Path it returns leads to whichever of those is closest, which
+		// needn't be as close as the Cell A* finds; here, no jump point lies beside the wall.
+		options := &PathOptions{Movement: MoveDiagonal, AllowPartial: true, Algorithm: algorithm}
+		result := m.SearchFromCells(start, dest, options)
+		if result.Err != nil || !result.Partial {
+			t.Fatalf("algorithm %d: partial search returned %v (partial: %t)", algorithm, result.Err, result.Partial)
+		}
+		if cost, bad := pathCost(m, result.Path, options); bad != "" || math.Abs(cost-result.Cost) > 1e-9 {
+			t.Fatalf("algorithm %d: partial search returned a Path costing %f (%s), but reported %f", algorithm, cost, bad, result.Cost)
+		}
+		last, best := result.Path.Cells[len(result.Path.Cells)-1], closest.Path.Cells[len(closest.Path.Cells)-1]
+		if Euclidean.Estimate(last, dest) < Euclidean.Estimate(best, dest) {
+			t.Fatalf("algorithm %d: partial search led to %s, closer than the closest reachable Cell, %s", algorithm, last, best)
+		}
+		if last == best {
+			t.Fatalf("algorithm %d: partial search led to %s, though no jump point lies beside the wall", algorithm, last)
+		}
+
+	}
+
+}
+
+func BenchmarkGetPathFromCells(b *testing.B) {
+	m := benchmarkGrid()
+	start, dest := m.Get(0, 0), m.Get(999, 999)
+	options := &PathOptions{Heuristic: Manhattan}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		m.GetPathFromCells(start, dest, options)
+	}
+}
+
+func BenchmarkPathfinderSearch(b *testing.B) {
+	m := benchmarkGrid()
+	pf := NewPathfinder(m)
+	start, dest := m.Get(0, 0), m.Get(999, 999)
+	options := &PathOptions{Heuristic: Manhattan}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		pf.Search(start, dest, options)
+	}
+}
+
+func TestGridDataMatchesGet(t *testing.T) {
+
+	m := NewGridFromStringArrays([]string{
+		"xxxx",
+		"x",
+		"",
+		"x  x x",
+		"xx",
+	}, 16, 16)
+
+	if m.Width() != 6 || m.Height() != 5 {
+		t.Fatalf("ragged Grid is %dx%d, not 6x5", m.Width(), m.Height())
+	}
+
+	for y := 0; y < m.Height(); y++ {
+		if len(m.Data[y]) != m.Width() {
+			t.Fatalf("row %d of Data has %d Cells, not %d", y, len(m.Data[y]), m.Width())
+		}
+		for x := 0; x < m.Width(); x++ {
+			if cell := m.Get(x, y); m.Data[y][x] != cell || cell.X != x || cell.Y != y {
+				t.Fatalf("Data[%d][%d] is %v, but Get(%d, %d) is %v", y, x, m.Data[y][x], x, y, cell)
+			}
+		}
+	}
+
+	if m.Get(3, 1).Rune != ' ' || m.Get(5, 3).Rune != 'x' {
+		t.Fatalf("ragged rows weren't filled out with blank Cells")
+	}
+
+}
+
+func BenchmarkNewGrid(b *testing.B) {
+	for _, size := range []int{1000, 2000} {
+		b.Run(fmt.Sprintf("%dx%d", size, size), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				NewGrid(size, size, 16, 16)
+			}
+		})
+	}
+}
+
+func BenchmarkSearchLargeGrid(b *testing.B) {
+	for _, size := range []int{1000, 2000} {
+		m := randomGrid(rand.New(rand.NewSource(1)), size, size, 0.2, false)
+		start, dest := m.Get(0, 0), m.Get(size-1, size-1)
+		start.Walkable, dest.Walkable = true, true
+		options := &PathOptions{Movement: MoveDiagonal, Heuristic: Octile}
+		b.Run(fmt.Sprintf("%dx%d", size, size), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				m.SearchFromCells(start, dest, options)
+			}
+		})
+	}
+}
+
+func TestGridLiteral(t *testing.T) {
+
+	// Grids put together by hand only have their Data, which they read their Cells from.
+	data := make([][]*Cell, 4)
+	for y := range data {
+		for x := 0; x < 5; x++ {
+			data[y] = append(data[y], &Cell{X: x, Y: y, Cost: 1, Walkable: true, Rune: '.'})
+		}
+	}
+	m := &Grid{Data: data, CellWidth: 16, CellHeight: 16}
+
+	if m.Width() != 5 || m.Height() != 4 || m.Nodes() != 20 || len(m.AllCells()) != 20 {
+		t.Fatalf("Grid literal is %dx%d with %d Cells, not 5x4", m.Width(), m.Height(), len(m.AllCells()))
+	}
+
+	// Replacing a Cell in Data replaces it in the Grid, too.
+	wall := &Cell{X: 2, Y: 1, Cost: 1, Walkable: false, Rune: 'x'}
+	m.Data[1][2] = wall
+	if m.Get(2, 1) != wall || m.GetIndex(m.Index(2, 1)) != wall || len(m.CellsByRune('x')) != 1 {
+		t.Fatalf("Grid literal doesn't see the Cell replaced in its Data")
+	}
+
+	for y := 0; y < 4; y++ {
+		m.Get(2, y).Walkable = false
+	}
+
+	result := m.SearchFromCells(m.Get(0, 0), m.Get(4, 0), nil)
+	if result.Err != ErrNoPath {
+		t.Fatalf("search across a walled-off Grid literal found %v, not ErrNoPath", result.Err)
+	}
+
+	m.Get(2, 3).Walkable = true
+	result = m.SearchFromCells(m.Get(0, 0), m.Get(4, 0), nil)
+	if result.Err != nil || result.Path.Length() != 11 || result.Path.Cells[5] != m.Data[3][2] {
+		t.Fatalf("search across a Grid literal found %v, with Path %v", result.Err, result.Path)
+	}
+
+}
+
+func BenchmarkThetaStar(b *testing.B) {
+	m := benchmarkGrid()
+	start, dest := m.Get(0, 0), m.Get(20, 20)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		m.ThetaStar(start, dest, nil)
+	}
+}
@@ -0,0 +1,280 @@
+package paths
+
+import (
+	"container/heap"
+	"math"
+)
+
+// A Planner finds a Path from a moving agent to a fixed destination, and efficiently repairs it as Cells change using
+// D* Lite. Rather than searching from scratch each time something changes, the Planner holds on to what it's learned and only
+// re-checks the Cells affected by the change. Searching happens backwards from the destination, so the agent can move along
+// the Path (using MoveTo()) without invalidating anything.
+//
+// After changing the walkability or Cost of Cells, pass them to UpdateCells() (or call Watch() beforehand to have the Planner do
+// that itself whenever the Grid records a change), and then call Path() to get the repaired Path. The Planner assumes that the
+// Grid doesn't change size. A Planner isn't safe to use from multiple goroutines at once.
+type Planner struct {
+	Grid    *Grid
+	Options *PathOptions
+
+	start, dest, last *Cell
+	km                float64
+
+	// costs holds each Cell's cost to reach the destination (g in D* Lite), while lookahead holds the one-step lookahead
+	// cost based on the Cell's neighbors (rhs). When they differ, the Cell is inconsistent and needs to be checked.
+	costs        []float64
+	lookahead    []float64
+	queue        plannerQueue
+	neighbors    []neighbor
+	predecessors []neighbor
+	watcher
+}
+
+// NewPlanner returns a new Planner that plans Paths from the start Cell to the destination Cell on the Grid provided. options
+// controls how Paths are found; its Heuristic (if any) is used to focus the search, though options like MaxNodes and
+// Algorithm don't apply.
+func NewPlanner(grid *Grid, start, dest *Cell, options *PathOptions) *Planner {
+
+	size := grid.Width() * grid.Height()
+
+	p := &Planner{
+		Grid:      grid,
+		Options:   options,
+		start:     start,
+		dest:      dest,
+		last:      start,
+		costs:     make([]float64, size),
+		lookahead: make([]float64, size),
+		queue:     plannerQueue{position: make([]int, size), keys: make([][2]float64, size)},
+	}
+
+	for i := range p.costs {
+		p.costs[i] = math.Inf(1)
+		p.lookahead[i] = math.Inf(1)
+		p.queue.position[i] = -1
+	}
+
+	d := p.index(dest)
+	p.lookahead[d] = 0
+	p.queue.push(d, p.key(d))
+	p.target = p
+
+	return p
+
+}
+
+// Start returns the Cell the Planner is currently planning from.
+func (p *Planner) Start() *Cell {
+	return p.start
+}
+
+// Dest returns the Cell the Planner is planning to.
+func (p *Planner) Dest() *Cell {
+	return p.dest
+}
+
+// MoveTo moves the start of the Planner to the Cell provided, which is usually the next Cell on the Path as the agent walks
+// along it. It can be any Cell, though, if the agent is pushed off course, whether or not any Cells change; the next call to
+// Path() plans from there.
+func (p *Planner) MoveTo(cell *Cell) {
+	p.start = cell
+}
+
+// UpdateCells informs the Planner that the walkability or Cost of the Cells provided has changed, so that it can repair its
+// Path the next time Path() is called.
+func (p *Planner) UpdateCells(cells ...*Cell) {
+
+	p.catchUp()
+
+	for _, cell := range p.Grid.appendAffected(nil, cells) {
+		p.updateCell(cell)
+	}
+
+}
+
+func (p *Planner) watchedGrid() *Grid {
+	return p.Grid
+}
+
+// Path returns the cheapest Path from the Planner's current start Cell to its destination. As with Grid.GetPathFromCells(),
+// it returns nil if either Cell isn't walkable, and an empty Path if there's no route between them.
+func (p *Planner) Path() *Path {
+
+	if !p.start.Walkable || !p.dest.Walkable {
+		return nil
+	}
+
+	p.computeShortestPath()
+
+	path := &Path{}
+	s := p.index(p.start)
+
+	if math.IsInf(p.costs[s], 1) {
+		return path
+	}
+
+	// Follow the cheapest neighbors down to the destination. The number of steps is limited to the number of Cells, just in case.
+	cell := p.start
+	path.Cells = append(path.Cells, cell)
+
+	for steps := 0; cell != p.dest && steps < len(p.costs); steps++ {
+
+		var best *Cell
+		bestCost := math.Inf(1)
+
+		p.neighbors = p.Grid.appendNeighbors(p.neighbors[:0], cell, p.Options)
+		for _, n := range p.neighbors {
+			if cost := n.Cost + p.costs[p.index(n.Cell)]; cost < bestCost {
+				best = n.Cell
+				bestCost = cost
+			}
+		}
+
+		if best == nil {
+			return &Path{}
+		}
+
+		cell = best
+		path.Cells = append(path.Cells, cell)
+
+	}
+
+	return path
+
+}
+
+func (p *Planner) index(cell *Cell) int {
+	return cell.Y*p.Grid.Width() + cell.X
+}
+
+func (p *Planner) estimate(from, to *Cell) float64 {
+	if h := p.Options.heuristic(); h != nil {
+		return h.Estimate(from, to) * p.Options.heuristicWeight()
+	}
+	return 0
+}
+
+// key returns the priority of the Cell at the index provided in the queue.
+func (p *Planner) key(i int) [2]float64 {
+	cost := math.Min(p.costs[i], p.lookahead[i])
+	cell := p.Grid.Get(i%p.Grid.Width(), i/p.Grid.Width())
+	return [2]float64{cost + p.estimate(p.start, cell) + p.km, cost}
+}
+
+// updateCell recalculates the lookahead cost of the Cell provided, and queues it to be checked if it's inconsistent.
+func (p *Planner) updateCell(cell *Cell) {
+
+	i := p.index(cell)
+
+	if cell != p.dest {
+		best := math.Inf(1)
+		if cell.Walkable {
+			p.neighbors = p.Grid.appendNeighbors(p.neighbors[:0], cell, p.Options)
+			for _, n := range p.neighbors {
+				best = math.Min(best, n.Cost+p.costs[p.index(n.Cell)])
+			}
+		}
+		p.lookahead[i] = best
+	}
+
+	p.queue.remove(i)
+	if p.costs[i] != p.lookahead[i] {
+		p.queue.push(i, p.key(i))
+	}
+
+}
+
+// updatePredecessors updates each Cell that can move onto the Cell provided.
+func (p *Planner) updatePredecessors(cell *Cell) {
+	p.predecessors = p.Grid.appendPredecessors(p.predecessors[:0], cell, p.Options)
+	for _, n := range p.predecessors {
+		p.updateCell(n.Cell)
+	}
+}
+
+// catchUp brings the keys of the Cells in the queue up to date with the start, if it's moved since they were last brought up
+// to date. The heuristic is measured from the start, so when it moves, the keys of Cells already in the queue become outdated.
+// Rather than updating all of them, D* Lite adds the distance moved to the keys of any new ones.
+func (p *Planner) catchUp() {
+	p.km += p.estimate(p.last, p.start)
+	p.last = p.start
+}
+
+// computeShortestPath checks inconsistent Cells until the cost from the start to the destination is known.
+func (p *Planner) computeShortestPath() {
+
+	p.catchUp()
+	s := p.index(p.start)
+
+	for p.queue.Len() > 0 && (keyLess(p.queue.topKey(), p.key(s)) || p.lookahead[s] != p.costs[s]) {
+
+		oldKey := p.queue.topKey()
+		i := p.queue.pop()
+		cell := p.Grid.Get(i%p.Grid.Width(), i/p.Grid.Width())
+
+		if newKey := p.key(i); keyLess(oldKey, newKey) {
+			p.queue.push(i, newKey)
+		} else if p.costs[i] > p.lookahead[i] {
+			p.costs[i] = p.lookahead[i]
+			p.updatePredecessors(cell)
+		} else {
+			p.costs[i] = math.Inf(1)
+			p.updateCell(cell)
+			p.updatePredecessors(cell)
+		}
+
+	}
+
+}
+
+func keyLess(a, b [2]float64) bool {
+	return a[0] < b[0] || (a[0] == b[0] && a[1] < b[1])
+}
+
+// plannerQueue is a priority queue of Cell indices that keeps track of where each one is, so that they can be removed.
+type plannerQueue struct {
+	items    []int
+	position []int
+	keys     [][2]float64
+}
+
+func (q plannerQueue) Len() int           { return len(q.items) }
+func (q plannerQueue) Less(i, j int) bool { return keyLess(q.keys[q.items[i]], q.keys[q.items[j]]) }
+func (q plannerQueue) Swap(i, j int) {
+	q.items[i], q.items[j] = q.items[j], q.items[i]
+	q.position[q.items[i]] = i
+	q.position[q.items[j]] = j
+}
+
+func (q *plannerQueue) Push(x interface{}) {
+	i := x.(int)
+	q.position[i] = len(q.items)
+	q.items = append(q.items, i)
+}
+
+func (q *plannerQueue) Pop() interface{} {
+	n := len(q.items)
+	i := q.items[n-1]
+	q.items = q.items[:n-1]
+	q.position[i] = -1
+	return i
+}
+
+func (q *plannerQueue) push(i int, key [2]float64) {
+	q.keys[i] = key
+	heap.Push(q, i)
+}
+
+func (q *plannerQueue) pop() int {
+	return heap.Pop(q).(int)
+}
+
+func (q *plannerQueue) remove(i int) {
+	if q.position[i] >= 0 {
+		heap.Remove(q, q.position[i])
+	}
+}
+
+func (q *plannerQueue) topKey() [2]float64 {
+	return q.keys[q.items[0]]
+}
@@ -0,0 +1,68 @@
+package paths
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func TestPlannerMatchesSearch(t *testing.T) {
+
+	rng := rand.New(rand.NewSource(7))
+
+	for i := 0; i < 100; i++ {
+
+		m := randomGrid(rng, 30, 25, 0.2, true)
+		options := &PathOptions{Movement: Movement(i % 2), Heuristic: Manhattan}
+		if options.Movement == MoveDiagonal {
+			options.Heuristic = Octile
+		}
+
+		start, dest := randomCell(rng, m), randomCell(rng, m)
+		start.Walkable, dest.Walkable = true, true
+		p := NewPlanner(m, start, dest, options)
+
+		// The agent walks along the Path, sometimes several steps between changes, and sometimes several changes between steps.
+		for step := 0; step < 60; step++ {
+
+			path := p.Path()
+			want := m.SearchFromCells(p.Start(), dest, options)
+
+			if want.Err != nil {
+				if path == nil || len(path.Cells) != 0 {
+					t.Fatalf("Planner found a Path from %s, but a search found %v", p.Start(), want.Err)
+				}
+			} else {
+				cost, bad := pathCost(m, path, options)
+				if bad != "" || path.Cells[0] != p.Start() || path.Cells[len(path.Cells)-1] != dest {
+					t.Fatalf("Planner's Path from %s isn't valid (%s)", p.Start(), bad)
+				}
+				if math.Abs(cost-want.Cost) > 1e-9 {
+					t.Fatalf("Planner's Path from %s costs %f, but a search found one costing %f", p.Start(), cost, want.Cost)
+				}
+				// Sometimes, the agent is pushed off course.
+				if next := randomCell(rng, m); rng.Intn(4) == 0 && next.Walkable {
+					p.MoveTo(next)
+				} else {
+					p.MoveTo(path.Cells[min(1+rng.Intn(4), len(path.Cells)-1)])
+				}
+			}
+
+			if rng.Intn(3) == 0 {
+				changed := []*Cell{}
+				for c := rng.Intn(4); c >= 0; c-- {
+					cell := randomCell(rng, m)
+					if cell != p.Start() && cell != dest {
+						cell.Walkable = rng.Intn(3) > 0
+						cell.Cost = float64(1 + rng.Intn(4))
+						changed = append(changed, cell)
+					}
+				}
+				p.UpdateCells(changed...)
+			}
+
+		}
+
+	}
+
+}
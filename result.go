@@ -0,0 +1,55 @@
+package paths
+
+import (
+	"errors"
+	"time"
+)
+
+var (
+	// ErrOutOfBounds is returned when the start or destination lies outside of the Grid.
+	ErrOutOfBounds = errors.New("paths: position is outside of the grid")
+	// ErrStartBlocked is returned when the starting Cell isn't walkable.
+	ErrStartBlocked = errors.New("paths: start cell isn't walkable")
+	// ErrDestBlocked is returned when the destination Cell isn't walkable.
+	ErrDestBlocked = errors.New("paths: destination cell isn't walkable")
+	// ErrNoPath is returned when there's no route from the start to the destination.
+	ErrNoPath = errors.New("paths: no path to the destination")
+	// ErrSearchLimit is returned when the search gave up before finding a Path because it reached PathOptions.MaxNodes or
+	// PathOptions.MaxCost. A Path may still exist.
+	ErrSearchLimit = errors.New("paths: search limit reached before finding a path")
+)
+
+// SearchStats are the parts of a search's outcome shared by every kind of search. Each kind of result embeds them alongside
+// whatever it found.
+type SearchStats struct {
+	// Err indicates why no Path was found (i.e. ErrNoPath or ErrStartBlocked); it's nil if the search succeeded.
+	Err error
+	// Cost is the total cost of moving along the Path, including the Cost of the starting Cell.
+	Cost float64
+	// Partial is true if the destination couldn't be reached, and the Path leads to the Cell closest to it instead (see
+	// PathOptions.AllowPartial).
+	Partial bool
+	// NodesExpanded is the number of Cells (or nodes) checked during the search.
+	NodesExpanded int
+	// Duration is how long the search took.
+	Duration time.Duration
+}
+
+// A PathResult is the outcome of a search, along with some statistics about it.
+type PathResult struct {
+	// Path is the Path found, or nil if Err is set.
+	Path *Path
+	SearchStats
+}
+
+// pathFromResult returns the Path from the result provided in the form Grid.GetPathFromCells() has always returned it: nil if
+// the start or destination couldn't be used, and an empty Path if the search came up empty.
+func pathFromResult(result *PathResult) *Path {
+	switch result.Err {
+	case nil:
+		return result.Path
+	case ErrNoPath, ErrSearchLimit:
+		return &Path{}
+	}
+	return nil
+}
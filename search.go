@@ -0,0 +1,159 @@
+package paths
+
+import (
+	"context"
+	"time"
+)
+
+// contextCheckInterval is how many Cells Search.Run() checks between checking whether its context is done.
+const contextCheckInterval = 256
+
+// A Search is a search for a Path that can be run a little at a time, so that a long search can be spread out over several
+// game frames rather than stalling one. Call Step() each frame until it returns true, and then get the result with Result().
+// A Search can also be run to completion (or until it's canceled) using Run().
+type Search struct {
+	pathfinder *Pathfinder
+	grid       *Grid
+	result     *PathResult
+	elapsed    time.Duration
+}
+
+// NewSearch starts a new Search for a Path from the starting Cell to the destination Cell on the Grid, as with
+// GetPathFromCells(). Nothing is searched until the Search is stepped or run.
+func (m *Grid) NewSearch(start, dest *Cell, options *PathOptions) *Search {
+
+	pf, ok := m.pathfinders.Get().(*Pathfinder)
+	if !ok {
+		pf = NewPathfinder(m)
+	}
+
+	s := pf.NewSearch(start, dest, options)
+	s.grid = m
+	if s.result != nil {
+		s.finish()
+	}
+	return s
+
+}
+
+// SearchContext searches for a Path from the starting Cell to the destination Cell, as with SearchFromCells(), but gives up
+// if the context provided is canceled (or times out) first. In that case, the result's Err is the context's error.
+func (m *Grid) SearchContext(ctx context.Context, start, dest *Cell, options *PathOptions) *PathResult {
+	return m.NewSearch(start, dest, options).Run(ctx)
+}
+
+// NewSearch starts a new Search for a Path from the starting Cell to the destination Cell using the Pathfinder. The
+// Pathfinder can't be used for anything else until the Search is done.
+func (pf *Pathfinder) NewSearch(start, dest *Cell, options *PathOptions) *Search {
+
+	s := &Search{pathfinder: pf}
+
+	if err := checkEnds(start, dest); err != nil && (err != ErrDestBlocked || !options.allowPartial()) {
+		s.result = &PathResult{SearchStats: SearchStats{Err: err}}
+		return s
+	}
+
+	pf.begin(start, dest, options)
+	return s
+
+}
+
+// Step checks up to the given number of Cells, returning true once the Search is done. A number below 1 checks one Cell, so
+// that calling Step() repeatedly always finishes.
+func (s *Search) Step(cells int) bool {
+
+	if s.result != nil {
+		return true
+	}
+
+	if cells < 1 {
+		cells = 1
+	}
+
+	began := time.Now()
+	pf := s.pathfinder
+	target := pf.search.checked + cells
+
+	for pf.search.checked < target {
+		if pf.search.step() {
+			s.elapsed += time.Since(began)
+			s.finish()
+			return true
+		}
+	}
+
+	s.elapsed += time.Since(began)
+	return false
+
+}
+
+// Run runs the Search until it's done, or until the context provided is canceled (or times out), and returns the result. If
+// the context is done first, the result's Err is the context's error, and the Search is canceled.
+func (s *Search) Run(ctx context.Context) *PathResult {
+
+	for !s.Step(contextCheckInterval) {
+		if err := ctx.Err(); err != nil {
+			s.cancel(err)
+			break
+		}
+	}
+
+	return s.result
+
+}
+
+// Cancel stops the Search; its result's Err will be context.Canceled.
+func (s *Search) Cancel() {
+	if s.result == nil {
+		s.cancel(context.Canceled)
+	}
+}
+
+// Done returns whether the Search has finished (or been canceled).
+func (s *Search) Done() bool {
+	return s.result != nil
+}
+
+// NodesExpanded returns the number of Cells checked so far.
+func (s *Search) NodesExpanded() int {
+	if s.result != nil {
+		return s.result.NodesExpanded
+	}
+	return s.pathfinder.search.checked
+}
+
+// Result returns the result of the Search, or nil if it isn't done yet.
+func (s *Search) Result() *PathResult {
+	return s.result
+}
+
+// Path returns the Path found by the Search, in the same form as Grid.GetPathFromCells() (so nil if the start or destination
+// couldn't be used, and an empty Path if no Path was found). If the Search isn't done yet (or was canceled), Path returns nil.
+func (s *Search) Path() *Path {
+	if s.result == nil {
+		return nil
+	}
+	return pathFromResult(s.result)
+}
+
+func (s *Search) cancel(err error) {
+	s.result = &PathResult{SearchStats: SearchStats{Err: err, NodesExpanded: s.pathfinder.search.checked, Duration: s.elapsed}}
+	s.release()
+}
+
+// finish records the result of the Search once it's done.
+func (s *Search) finish() {
+	if s.result == nil {
+		s.result = s.pathfinder.result()
+		s.result.Duration = s.elapsed
+	}
+	s.release()
+}
+
+// release returns the Pathfinder to the Grid it was borrowed from, if any.
+func (s *Search) release() {
+	if s.grid != nil {
+		s.grid.pathfinders.Put(s.pathfinder)
+		s.grid = nil
+	}
+}
@@ -0,0 +1,170 @@
+package paths
+
+import (
+	"errors"
+	"runtime"
+	"sync"
+)
+
+// ErrServiceClosed is returned for requests made to a PathService after it's been closed.
+var ErrServiceClosed = errors.New("paths: path service is closed")
+
+// A PathService finds Paths on a shared Grid in the background, using a fixed number of worker goroutines. Requests can be made
+// from any number of goroutines at once, and their results are delivered either through a channel (Request()) or to a
+// callback (RequestFunc()). Identical requests (with the same start Cell, destination Cell, and *PathOptions pointer) made
+// while one is already waiting or being searched are only searched once, with each requester receiving its own copy of the
+// result.
+//
+// The workers read-lock the Grid while searching it, so while a PathService is running, any changes to the Grid or its Cells
+// should be made between calls to Grid.Lock() and Grid.Unlock().
+type PathService struct {
+	Grid *Grid
+
+	mutex   sync.Mutex
+	ready   *sync.Cond
+	queue   []*pathJob
+	pending map[pathKey]*pathJob
+	closed  bool
+	workers sync.WaitGroup
+}
+
+// pathKey identifies identical requests.
+type pathKey struct {
+	start, dest *Cell
+	options     *PathOptions
+}
+
+// pathJob is a request waiting to be searched, along with everyone waiting on it.
+type pathJob struct {
+	key       pathKey
+	callbacks []func(*PathResult)
+}
+
+// NewPathService returns a new PathService that finds Paths on the Grid provided using the given number of worker goroutines.
+// If workers is less than 1, one worker is started for each CPU.
+func NewPathService(grid *Grid, workers int) *PathService {
+
+	if workers < 1 {
+		workers = runtime.NumCPU()
+	}
+
+	s := &PathService{
+		Grid:    grid,
+		pending: map[pathKey]*pathJob{},
+	}
+	s.ready = sync.NewCond(&s.mutex)
+
+	s.workers.Add(workers)
+	for i := 0; i < workers; i++ {
+		go s.work()
+	}
+
+	return s
+
+}
+
+// Request requests a Path from the starting Cell to the destination Cell, returning a channel that receives the result once
+// it's ready. The channel is buffered, so the result is never lost if it isn't received right away.
+func (s *PathService) Request(start, dest *Cell, options *PathOptions) <-chan *PathResult {
+	results := make(chan *PathResult, 1)
+	s.RequestFunc(start, dest, options, func(result *PathResult) { results <- result })
+	return results
+}
+
+// RequestFunc requests a Path from the starting Cell to the destination Cell, calling the callback provided with the result once
+// it's ready. The callback is called from one of the PathService's worker goroutines (or right away, if the PathService is
+// closed), so it should return quickly.
+func (s *PathService) RequestFunc(start, dest *Cell, options *PathOptions, callback func(*PathResult)) {
+
+	s.mutex.Lock()
+
+	if s.closed {
+		s.mutex.Unlock()
+		callback(&PathResult{SearchStats: SearchStats{Err: ErrServiceClosed}})
+		return
+	}
+
+	key := pathKey{start, dest, options}
+	if job, ok := s.pending[key]; ok {
+		job.callbacks = append(job.callbacks, callback)
+	} else {
+		job = &pathJob{key: key, callbacks: []func(*PathResult){callback}}
+		s.pending[key] = job
+		s.queue = append(s.queue, job)
+		s.ready.Signal()
+	}
+
+	s.mutex.Unlock()
+
+}
+
+// Pending returns the number of requests that haven't been answered yet (not counting duplicates).
+func (s *PathService) Pending() int {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return len(s.pending)
+}
+
+// Close stops the PathService from accepting new requests, and waits for the requests already made to be answered before
+// returning.
+func (s *PathService) Close() {
+
+	s.mutex.Lock()
+	s.closed = true
+	s.ready.Broadcast()
+	s.mutex.Unlock()
+
+	s.workers.Wait()
+
+}
+
+// work answers requests until the PathService is closed and there are none left.
+func (s *PathService) work() {
+
+	defer s.workers.Done()
+
+	pf := NewPathfinder(s.Grid)
+
+	for {
+
+		s.mutex.Lock()
+		for len(s.queue) == 0 && !s.closed {
+			s.ready.Wait()
+		}
+		if len(s.queue) == 0 {
+			s.mutex.Unlock()
+			return
+		}
+		job := s.queue[0]
+		s.queue[0] = nil
+		s.queue = s.queue[1:]
+		s.mutex.Unlock()
+
+		s.Grid.RLock()
+		result := pf.Search(job.key.start, job.key.dest, job.key.options)
+		s.Grid.RUnlock()
+
+		// Once the job's no longer pending, no more callbacks can be added to it.
+		s.mutex.Lock()
+		delete(s.pending, job.key)
+		s.mutex.Unlock()
+
+		// The last requester gets the original result, and the rest get copies made before it's handed out.
+		last := len(job.callbacks) - 1
+		for _, callback := range job.callbacks[:last] {
+			callback(result.copy())
+		}
+		job.callbacks[last](result)
+
+	}
+
+}
+
+// copy returns a copy of the PathResult with its own Path, so that it can be followed separately.
+func (r *PathResult) copy() *PathResult {
+	c := *r
+	if r.Path != nil {
+		c.Path = &Path{Cells: append([]*Cell(nil), r.Path.Cells...), CurrentIndex: r.Path.CurrentIndex}
+	}
+	return &c
+}
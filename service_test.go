@@ -0,0 +1,155 @@
+package paths
+
+import (
+	"math/rand"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestPathServiceConcurrent(t *testing.T) {
+
+	rng := rand.New(rand.NewSource(13))
+	m := randomGrid(rng, 40, 40, 0.2, true)
+	options := &PathOptions{Movement: MoveDiagonal, Heuristic: Octile}
+
+	pairs := make([][2]*Cell, 20)
+	for i := range pairs {
+		pairs[i] = [2]*Cell{randomCell(rng, m), randomCell(rng, m)}
+	}
+
+	s := NewPathService(m, 4)
+	done := make(chan struct{})
+	edits := sync.WaitGroup{}
+	edits.Add(1)
+
+	// While the requests are made, another goroutine keeps changing the Grid, locking it to do so.
+	go func() {
+		defer edits.Done()
+		rng := rand.New(rand.NewSource(14))
+		for {
+			select {
+			case <-done:
+				return
+			default:
+			}
+			m.Lock()
+			m.SetCellWalkable(m.Get(rng.Intn(40), rng.Intn(40)), rng.Intn(3) > 0)
+			m.Unlock()
+		}
+	}()
+
+	requesters := sync.WaitGroup{}
+	for r := 0; r < 16; r++ {
+		requesters.Add(1)
+		go func(r int) {
+			defer requesters.Done()
+			for i := 0; i < 50; i++ {
+				pair := pairs[(r*7+i)%len(pairs)]
+				result := <-s.Request(pair[0], pair[1], options)
+				if result.Err == nil && (result.Path.Cells[0] != pair[0] || result.Path.Cells[len(result.Path.Cells)-1] != pair[1]) {
+					t.Errorf("PathService returned a Path from %s to %s for a request from %s to %s", result.Path.Cells[0], result.Path.Cells[len(result.Path.Cells)-1], pair[0], pair[1])
+				}
+			}
+		}(r)
+	}
+
+	requesters.Wait()
+	close(done)
+	edits.Wait()
+	s.Close()
+
+}
+
+func TestPathServiceDeduplicates(t *testing.T) {
+
+	m := NewGrid(20, 20, 16, 16)
+	start, dest := m.Get(0, 0), m.Get(19, 12)
+
+	// The CostFunc counts how many steps are checked, which is the same for every search between the same Cells.
+	var steps int64
+	options := &PathOptions{
+		CostFunc: func(from, to *Cell, cost float64, agent interface{}) float64 {
+			atomic.AddInt64(&steps, 1)
+			return cost
+		},
+	}
+	m.SearchFromCells(start, dest, options)
+	once := atomic.LoadInt64(&steps)
+	atomic.StoreInt64(&steps, 0)
+
+	// Holding the Grid's lock keeps the first request from being answered before the rest are made.
+	s := NewPathService(m, 2)
+	m.Lock()
+	results := []<-chan *PathResult{}
+	for i := 0; i < 5; i++ {
+		results = append(results, s.Request(start, dest, options))
+	}
+	if pending := s.Pending(); pending != 1 {
+		t.Errorf("PathService has %d requests pending, not 1", pending)
+	}
+	m.Unlock()
+
+	paths := map[*Path]bool{}
+	for _, r := range results {
+		result := <-r
+		if result.Err != nil {
+			t.Fatalf("PathService failed to find a Path: %v", result.Err)
+		}
+		if paths[result.Path] {
+			t.Fatalf("PathService gave the same Path to more than one requester")
+		}
+		paths[result.Path] = true
+	}
+
+	s.Close()
+
+	if searched := atomic.LoadInt64(&steps); searched != once {
+		t.Fatalf("PathService checked %d steps for identical requests, but one search checks %d", searched, once)
+	}
+
+}
+
+func TestPathServiceClose(t *testing.T) {
+
+	before := runtime.NumGoroutine()
+
+	m := NewGrid(30, 30, 16, 16)
+	s := NewPathService(m, 4)
+
+	// The requests made before closing are still answered, even if they're still waiting when Close() is called.
+	m.Lock()
+	results := []<-chan *PathResult{}
+	for i := 0; i < 30; i++ {
+		results = append(results, s.Request(m.Get(0, i), m.Get(29, 29-i), nil))
+	}
+
+	closed := make(chan struct{})
+	go func() {
+		s.Close()
+		close(closed)
+	}()
+	m.Unlock()
+
+	for _, r := range results {
+		if result := <-r; result.Err != nil {
+			t.Fatalf("request made before closing failed: %v", result.Err)
+		}
+	}
+	<-closed
+
+	if result := <-s.Request(m.Get(0, 0), m.Get(1, 1), nil); result.Err != ErrServiceClosed {
+		t.Fatalf("request made after closing returned %v, not ErrServiceClosed", result.Err)
+	}
+
+	// Once closed, the workers are all gone.
+	for wait := 0; runtime.NumGoroutine() > before; wait++ {
+		if wait > 100 {
+			t.Fatalf("%d goroutines are still running after closing, up from %d", runtime.NumGoroutine(), before)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+}
@@ -0,0 +1,214 @@
+package paths
+
+import "math"
+
+// A RaycastHit describes where a ray cast across a Grid was stopped (see Grid.Raycast()).
+type RaycastHit struct {
+	// Cell is the first Cell along the ray that isn't walkable.
+	Cell *Cell
+	// Point is the world position at which the ray enters the Cell.
+	Point Vector
+	// Normal is the direction the side of the Cell that the ray enters through faces: (-1, 0) for its left side, (0, 1) for its
+	// bottom, and so on. A ray entering exactly through a corner gets a diagonal Normal between the two sides, while a ray
+	// starting inside the Cell gets a zero Normal.
+	Normal Vector
+	// Distance is the distance in the world from the start of the ray to Point.
+	Distance float64
+}
+
+// LineOfSight returns whether the two Cells provided can see each other: that is, whether every Cell touched by a straight line
+// between their centers is walkable. Where the line passes exactly through the corner between Cells, it touches (and so can be
+// blocked by) the Cells on both sides of it. The Cells at either end don't block the line themselves, so a Cell can see a wall.
+func (m *Grid) LineOfSight(from, to *Cell) bool {
+	return m.traceLine(from, to, func(prev, next *Cell, diagonal bool) bool {
+		if diagonal && !(m.walkable(next.X, prev.Y) && m.walkable(prev.X, next.Y)) {
+			return false
+		}
+		return next == to || next.Walkable
+	})
+}
+
+// LineOfSightBresenham returns whether the two Cells provided can see each other along the line drawn between them by
+// Bresenham's line algorithm, which steps through a single Cell per row or column (whichever there are more of). This lets the
+// line slip diagonally between walls that only touch at their corners, so it's more permissive than LineOfSight(), but it's
+// also what the line looks like when it's drawn Cell by Cell. The Cells at either end don't block the line themselves.
+func (m *Grid) LineOfSightBresenham(from, to *Cell) bool {
+
+	dx, dy := abs(to.X-from.X), -abs(to.Y-from.Y)
+	sx, sy := sign(to.X-from.X), sign(to.Y-from.Y)
+	err := dx + dy
+
+	for x, y := from.X, from.Y; x != to.X || y != to.Y; {
+
+		e2 := 2 * err
+		if e2 >= dy {
+			err += dy
+			x += sx
+		}
+		if e2 <= dx {
+			err += dx
+			y += sy
+		}
+
+		if (x != to.X || y != to.Y) && !m.walkable(x, y) {
+			return false
+		}
+
+	}
+
+	return true
+
+}
+
+// CellsAlongSegment calls visit with each Cell that the line segment between two world positions passes through, in order
+// from the start to the end, stopping early if visit returns false. Where the segment passes exactly through the corner between
+// Cells, the Cells on both sides of it are visited before the one diagonally across. Parts of the segment outside of the Grid
+// are skipped.
+func (m *Grid) CellsAlongSegment(startX, startY, endX, endY float64, visit func(cell *Cell) bool) {
+	m.walkSegment(startX, startY, endX, endY, func(cell *Cell, t float64) bool {
+		return visit(cell)
+	})
+}
+
+// Raycast casts a ray from one world position to another, and returns where it's stopped by the first Cell along the way that
+// isn't walkable (as with CellsAlongSegment(), a ray passing exactly through the corner between Cells is stopped by either of
+// them). If the ray starts inside a Cell that isn't walkable, it's stopped right away. If nothing stops the ray, Raycast
+// returns nil. Parts of the ray outside of the Grid pass through freely.
+func (m *Grid) Raycast(startX, startY, endX, endY float64) *RaycastHit {
+
+	var hit *RaycastHit
+
+	m.walkSegment(startX, startY, endX, endY, func(cell *Cell, t float64) bool {
+		if cell.Walkable {
+			return true
+		}
+		dx, dy := (endX-startX)*t, (endY-startY)*t
+		hit = &RaycastHit{
+			Cell:     cell,
+			Point:    Vector{startX + dx, startY + dy},
+			Distance: math.Hypot(dx, dy),
+		}
+		if t > 0 {
+			hit.Normal = m.entryNormal(cell, hit.Point, endX-startX, endY-startY)
+		}
+		return false
+	})
+
+	return hit
+
+}
+
+// entryNormal returns the Normal of the side of the Cell provided that a ray heading in the direction given enters through at
+// the world position provided.
+func (m *Grid) entryNormal(cell *Cell, point Vector, dx, dy float64) Vector {
+
+	cw, ch := float64(m.CellWidth), float64(m.CellHeight)
+	normal := Vector{}
+
+	if dx > 0 && math.Abs(point.X-float64(cell.X)*cw) < 1e-9*cw {
+		normal.X = -1
+	} else if dx < 0 && math.Abs(point.X-float64(cell.X+1)*cw) < 1e-9*cw {
+		normal.X = 1
+	}
+	if dy > 0 && math.Abs(point.Y-float64(cell.Y)*ch) < 1e-9*ch {
+		normal.Y = -1
+	} else if dy < 0 && math.Abs(point.Y-float64(cell.Y+1)*ch) < 1e-9*ch {
+		normal.Y = 1
+	}
+
+	if normal.X != 0 && normal.Y != 0 {
+		normal.X *= math.Sqrt2 / 2
+		normal.Y *= math.Sqrt2 / 2
+	}
+
+	return normal
+
+}
+
+// walkSegment walks the Cells that the line segment between two world positions passes through, calling visit with each Cell
+// and how far along the segment (from 0 to 1) the Cell is entered. Positions outside of the Grid are skipped.
+func (m *Grid) walkSegment(startX, startY, endX, endY float64, visit func(cell *Cell, t float64) bool) {
+
+	cw, ch := float64(m.CellWidth), float64(m.CellHeight)
+	gx, gy := startX/cw, startY/ch
+	dx, dy := endX/cw-gx, endY/ch-gy
+
+	x, y := int(math.Floor(gx)), int(math.Floor(gy))
+	nx := abs(int(math.Floor(endX/cw)) - x)
+	ny := abs(int(math.Floor(endY/ch)) - y)
+
+	// tx and ty are how far along the segment the next vertical and horizontal Cell borders are crossed, while stepX and stepY
+	// are how far along it each Cell is in either direction.
+	sx, sy := 0, 0
+	tx, ty := math.Inf(1), math.Inf(1)
+	stepX, stepY := math.Inf(1), math.Inf(1)
+
+	if nx > 0 {
+		sx = int(math.Copysign(1, dx))
+		stepX = math.Abs(1 / dx)
+		if sx > 0 {
+			tx = (float64(x+1) - gx) / dx
+		} else {
+			tx = (gx - float64(x)) / -dx
+		}
+	}
+	if ny > 0 {
+		sy = int(math.Copysign(1, dy))
+		stepY = math.Abs(1 / dy)
+		if sy > 0 {
+			ty = (float64(y+1) - gy) / dy
+		} else {
+			ty = (gy - float64(y)) / -dy
+		}
+	}
+
+	step := func(x, y int, t float64) bool {
+		if cell := m.Get(x, y); cell != nil {
+			return visit(cell, t)
+		}
+		return true
+	}
+
+	if !step(x, y, 0) {
+		return
+	}
+
+	for ix, iy := 0, 0; ix < nx || iy < ny; {
+
+		switch {
+
+		case ix < nx && iy < ny && math.Abs(tx-ty) < 1e-9:
+			// The segment passes right through a corner, touching the Cells on both sides of it. (The borders are compared with a
+			// little leeway, as rounding can keep them from lining up exactly.)
+			if !step(x+sx, y, tx) || !step(x, y+sy, ty) {
+				return
+			}
+			x, y = x+sx, y+sy
+			ix, iy = ix+1, iy+1
+			if !step(x, y, tx) {
+				return
+			}
+			tx += stepX
+			ty += stepY
+
+		case iy >= ny || (ix < nx && tx < ty):
+			x += sx
+			ix++
+			if !step(x, y, tx) {
+				return
+			}
+			tx += stepX
+
+		default:
+			y += sy
+			iy++
+			if !step(x, y, ty) {
+				return
+			}
+			ty += stepY
+
+		}
+
+	}
+
+}
@@ -0,0 +1,111 @@
+package paths
+
+import (
+	"math"
+	"testing"
+)
+
+func TestRaycast(t *testing.T) {
+
+	m := NewGrid(10, 10, 16, 16)
+	wall := m.Get(5, 3)
+	m.SetCellWalkable(wall, false)
+	diagonal := math.Sqrt2 / 2
+
+	for _, test := range []struct {
+		name           string
+		startX, startY float64
+		endX, endY     float64
+		point, normal  Vector
+		distance       float64
+	}{
+		{"from the left", 8, 56, 150, 56, Vector{80, 56}, Vector{-1, 0}, 72},
+		{"from the right", 150, 56, 0, 56, Vector{96, 56}, Vector{1, 0}, 54},
+		{"from above", 88, 0, 88, 150, Vector{88, 48}, Vector{0, -1}, 48},
+		{"from below", 88, 150, 88, 0, Vector{88, 64}, Vector{0, 1}, 86},
+		{"from outside of the Grid", -40, 56, 150, 56, Vector{80, 56}, Vector{-1, 0}, 120},
+		// Passing exactly through the wall's corner, between two walkable Cells, hits the wall at the corner.
+		{"through a corner", 48, 16, 112, 80, Vector{80, 48}, Vector{-diagonal, -diagonal}, math.Hypot(32, 32)},
+		{"from inside the wall", 88, 56, 150, 56, Vector{88, 56}, Vector{}, 0},
+	} {
+
+		hit := m.Raycast(test.startX, test.startY, test.endX, test.endY)
+		if hit == nil {
+			t.Fatalf("ray %s missed the wall", test.name)
+		}
+		if hit.Cell != wall {
+			t.Fatalf("ray %s hit %s, not the wall", test.name, hit.Cell)
+		}
+		if math.Abs(hit.Point.X-test.point.X) > 1e-9 || math.Abs(hit.Point.Y-test.point.Y) > 1e-9 {
+			t.Fatalf("ray %s hit at %v, not %v", test.name, hit.Point, test.point)
+		}
+		if math.Abs(hit.Normal.X-test.normal.X) > 1e-9 || math.Abs(hit.Normal.Y-test.normal.Y) > 1e-9 {
+			t.Fatalf("ray %s hit with a Normal of %v, not %v", test.name, hit.Normal, test.normal)
+		}
+		if math.Abs(hit.Distance-test.distance) > 1e-9 {
+			t.Fatalf("ray %s hit %f away, not %f", test.name, hit.Distance, test.distance)
+		}
+
+	}
+
+	if hit := m.Raycast(8, 8, 150, 8); hit != nil {
+		t.Fatalf("ray along an open row hit %s", hit.Cell)
+	}
+
+}
+
+func TestLineOfSightCorners(t *testing.T) {
+
+	for _, test := range []struct {
+		name                  string
+		walls                 []string
+		from, to              [2]int
+		supercover, bresenham bool
+	}{
+		{"across an open Grid", []string{
+			"   ",
+			"   ",
+			"   ",
+		}, [2]int{0, 0}, [2]int{2, 2}, true, true},
+		// A line passing exactly through the corner between two walls touches both, while Bresenham's slips between them.
+		{"between walls meeting at a corner", []string{
+			" x ",
+			"x  ",
+			"   ",
+		}, [2]int{0, 0}, [2]int{1, 1}, false, true},
+		// Either wall beside a corner blocks the line.
+		{"past one wall at a corner", []string{
+			" x ",
+			"   ",
+			"   ",
+		}, [2]int{0, 0}, [2]int{1, 1}, false, true},
+		{"through a wall", []string{
+			"   ",
+			" x ",
+			"   ",
+		}, [2]int{0, 0}, [2]int{2, 2}, false, false},
+		// The Cells at either end don't block the line.
+		{"to a wall", []string{
+			"   ",
+			"   ",
+			"  x",
+		}, [2]int{0, 0}, [2]int{2, 2}, true, true},
+	} {
+
+		m := NewGridFromStringArrays(test.walls, 16, 16)
+		m.SetWalkable('x', false)
+		from, to := m.Get(test.from[0], test.from[1]), m.Get(test.to[0], test.to[1])
+
+		// Lines of sight work the same both ways.
+		for _, ends := range [][2]*Cell{{from, to}, {to, from}} {
+			if got := m.LineOfSight(ends[0], ends[1]); got != test.supercover {
+				t.Fatalf("LineOfSight() %s from %s to %s is %t, not %t", test.name, ends[0], ends[1], got, test.supercover)
+			}
+			if got := m.LineOfSightBresenham(ends[0], ends[1]); got != test.bresenham {
+				t.Fatalf("LineOfSightBresenham() %s from %s to %s is %t, not %t", test.name, ends[0], ends[1], got, test.bresenham)
+			}
+		}
+
+	}
+
+}
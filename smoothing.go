@@ -0,0 +1,239 @@
+package paths
+
+import "math"
+
+// SimplifyPath returns a copy of the Path provided with the Cells in the middle of straight runs removed, leaving only the
+// start, the destination, and the Cells where the Path turns. Consecutive Cells in the simplified Path are generally no longer
+// neighbors; moving in a straight line from one to the next follows the original Path exactly, so it takes the same steps,
+// whatever PathOptions the Path was found with.
+func (m *Grid) SimplifyPath(path *Path) *Path {
+
+	simple := &Path{}
+	if len(path.Cells) == 0 {
+		return simple
+	}
+
+	simple.Cells = append(simple.Cells, path.Cells[0])
+
+	for i := 1; i < len(path.Cells)-1; i++ {
+		prev, cell, next := path.Cells[i-1], path.Cells[i], path.Cells[i+1]
+		if cell.X-prev.X != next.X-cell.X || cell.Y-prev.Y != next.Y-cell.Y {
+			simple.Cells = append(simple.Cells, cell)
+		}
+	}
+
+	if len(path.Cells) > 1 {
+		simple.Cells = append(simple.Cells, path.Cells[len(path.Cells)-1])
+	}
+
+	return simple
+
+}
+
+// StringPullPath returns a copy of the Path provided that skips any Cells it doesn't need to visit, like pulling a string
+// tight around the corners it bends past. From each Cell, the Path heads straight to the furthest Cell further along that it
+// can reach in a straight line. options should be the PathOptions the Path was found with. A straight line can only cross
+// walkable Cells, taking steps between them that options allow (so PassableFunc, edge costs, and CornerCutting all apply), and
+// none of its steps can cost more than the ones it replaces, so a Path that went around a swamp doesn't suddenly cut through it.
+// Steps are costed without any DiagonalCost. As with SimplifyPath(), consecutive Cells in the result are generally no longer
+// neighbors.
+func (m *Grid) StringPullPath(path *Path, options *PathOptions) *Path {
+
+	pulled := &Path{}
+	if len(path.Cells) == 0 {
+		return pulled
+	}
+
+	cells := path.Cells
+	pulled.Cells = append(pulled.Cells, cells[0])
+
+	for anchor := 0; anchor < len(cells)-1; {
+
+		next := anchor + 1
+		highest := m.adjustStep(cells[anchor], cells[next], cells[next].Cost, options)
+
+		for j := anchor + 2; j < len(cells); j++ {
+			highest = math.Max(highest, m.adjustStep(cells[j-1], cells[j], cells[j].Cost, options))
+			if !m.clearLine(cells[anchor], cells[j], highest, options) {
+				break
+			}
+			next = j
+		}
+
+		pulled.Cells = append(pulled.Cells, cells[next])
+		anchor = next
+
+	}
+
+	return pulled
+
+}
+
+// clearLine returns whether a straight line between the centers of two Cells only passes through walkable Cells, taking steps
+// that the PathOptions provided allow and that cost no more than the cost given.
+func (m *Grid) clearLine(from, to *Cell, maxCost float64, options *PathOptions) bool {
+	return m.traceLine(from, to, func(prev, next *Cell, diagonal bool) bool {
+		if !next.Walkable || (diagonal && m.cornerBlocked(prev, m.Get(next.X, prev.Y), m.Get(prev.X, next.Y), options)) {
+			return false
+		}
+		return m.adjustStep(prev, next, next.Cost, options) <= maxCost
+	})
+}
+
+// CatmullRomPath returns a smooth curve in world coordinates that passes through the centers of each Cell of the Path
+// provided, using a Catmull-Rom spline. Each span of the curve between two Cells is made of the number of segments given.
+// Simplifying the Path first (with SimplifyPath() or StringPullPath()) gives a more natural curve. Wherever the curve would
+// swing through a Cell that isn't walkable, or across a step between Cells that options (the PathOptions the Path was found
+// with) don't allow, that span is left as a straight line instead, so the curve never goes anywhere the Path itself can't.
+func (m *Grid) CatmullRomPath(path *Path, segments int, options *PathOptions) []Vector {
+
+	points := m.pathPoints(path)
+	if len(points) < 2 {
+		return points
+	}
+
+	segments = max(segments, 1)
+	curve := []Vector{points[0]}
+
+	for i := 0; i < len(points)-1; i++ {
+
+		// The ends of the Path are repeated so that the curve starts and ends on them.
+		p0, p1, p2, p3 := points[max(i-1, 0)], points[i], points[i+1], points[min(i+2, len(points)-1)]
+
+		span := make([]Vector, 0, segments)
+		for s := 1; s <= segments; s++ {
+			span = append(span, catmullRom(p0, p1, p2, p3, float64(s)/float64(segments)))
+		}
+
+		curve = m.appendCurve(curve, span, p2, options)
+
+	}
+
+	return curve
+
+}
+
+// BezierPath returns a smooth curve in world coordinates that follows the Path provided, rounding off each corner with a
+// quadratic Bezier curve. Each curve starts halfway along the line leading into the corner, bends towards the center of the
+// corner Cell, and ends halfway along the line leading out of it, and is made of the number of segments given. Unlike
+// CatmullRomPath(), the curve doesn't pass through the corner Cells themselves, but it never swings outside of the corners, so
+// it tends to hug them more tightly. Wherever a rounded corner would pass through a Cell that isn't walkable, or across a step
+// that options don't allow (as with CatmullRomPath()), the corner is left sharp.
+func (m *Grid) BezierPath(path *Path, segments int, options *PathOptions) []Vector {
+
+	points := m.pathPoints(path)
+	if len(points) < 3 {
+		return points
+	}
+
+	segments = max(segments, 1)
+	curve := []Vector{points[0]}
+
+	for i := 1; i < len(points)-1; i++ {
+
+		from := midpoint(points[i-1], points[i])
+		to := midpoint(points[i], points[i+1])
+		if from != curve[len(curve)-1] {
+			curve = append(curve, from)
+		}
+
+		span := make([]Vector, 0, segments)
+		for s := 1; s <= segments; s++ {
+			span = append(span, quadraticBezier(from, points[i], to, float64(s)/float64(segments)))
+		}
+
+		// If the rounded corner doesn't fit, the sharp one goes through the corner Cell instead.
+		if !m.curveClear(from, span, options) {
+			curve = append(curve, points[i])
+			span = span[len(span)-1:]
+		}
+		curve = append(curve, span...)
+
+	}
+
+	return append(curve, points[len(points)-1])
+
+}
+
+// pathPoints returns the world positions of the centers of the Cells of the Path provided.
+func (m *Grid) pathPoints(path *Path) []Vector {
+	points := make([]Vector, len(path.Cells))
+	for i, cell := range path.Cells {
+		points[i] = m.cellCenter(cell)
+	}
+	return points
+}
+
+// appendCurve appends the points of a span of a curve to it, or just the end of the span if the span isn't clear.
+func (m *Grid) appendCurve(curve, span []Vector, end Vector, options *PathOptions) []Vector {
+	if m.curveClear(curve[len(curve)-1], span, options) {
+		return append(curve, span...)
+	}
+	return append(curve, end)
+}
+
+// curveClear returns whether the line segments from the start provided through each of the points given are clear (see
+// segmentClear()).
+func (m *Grid) curveClear(start Vector, points []Vector, options *PathOptions) bool {
+	for _, p := range points {
+		if !m.segmentClear(start, p, options) {
+			return false
+		}
+		start = p
+	}
+	return true
+}
+
+// segmentClear returns whether the line segment between two world positions only passes through walkable Cells, stepping
+// between them in ways the PathOptions provided allow. Where the segment passes through the corner between Cells, both Cells
+// beside it are visited before the one across it, so each Cell is stepped onto from whichever of the last two visited is
+// orthogonally next to it.
+func (m *Grid) segmentClear(start, end Vector, options *PathOptions) bool {
+
+	clear := true
+	var last [2]*Cell
+
+	m.walkSegment(start.X, start.Y, end.X, end.Y, func(cell *Cell, t float64) bool {
+
+		if !cell.Walkable {
+			clear = false
+			return false
+		}
+
+		for _, prev := range last {
+			if prev != nil && abs(prev.X-cell.X)+abs(prev.Y-cell.Y) == 1 {
+				if math.IsInf(m.adjustStep(prev, cell, cell.Cost, options), 1) {
+					clear = false
+					return false
+				}
+				break
+			}
+		}
+
+		last = [2]*Cell{cell, last[0]}
+		return true
+
+	})
+
+	return clear
+
+}
+
+// catmullRom returns the point at t (from 0 to 1) along the Catmull-Rom spline from p1 to p2.
+func catmullRom(p0, p1, p2, p3 Vector, t float64) Vector {
+	t2, t3 := t*t, t*t*t
+	f := func(a, b, c, d float64) float64 {
+		return 0.5 * (2*b + (c-a)*t + (2*a-5*b+4*c-d)*t2 + (3*b-a-3*c+d)*t3)
+	}
+	return Vector{f(p0.X, p1.X, p2.X, p3.X), f(p0.Y, p1.Y, p2.Y, p3.Y)}
+}
+
+// quadraticBezier returns the point at t (from 0 to 1) along the quadratic Bezier curve from a to c, bending towards b.
+func quadraticBezier(a, b, c Vector, t float64) Vector {
+	u := 1 - t
+	return Vector{u*u*a.X + 2*u*t*b.X + t*t*c.X, u*u*a.Y + 2*u*t*b.Y + t*t*c.Y}
+}
+
+func midpoint(a, b Vector) Vector {
+	return Vector{(a.X + b.X) / 2, (a.Y + b.Y) / 2}
+}
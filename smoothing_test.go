@@ -0,0 +1,163 @@
+package paths
+
+import (
+	"testing"
+)
+
+// boatGrid returns a Grid with a ring of water ('~') around an island of land ('.'), along with PathOptions for a boat, which
+// can only move on water.
+func boatGrid() (*Grid, *PathOptions) {
+	m := NewGridFromStringArrays([]string{
+		"~~~~~~~~~",
+		"~.......~",
+		"~.......~",
+		"~.......~",
+		"~.......~",
+		"~.......~",
+		"~~~~~~~~~",
+	}, 16, 16)
+	options := &PathOptions{
+		Movement: MoveDiagonal,
+		PassableFunc: func(from, to *Cell, agent interface{}) bool {
+			return to.Rune == '~'
+		},
+	}
+	return m, options
+}
+
+// lineCells returns the Cells a straight line between the centers of two Cells steps onto, after the first.
+func lineCells(m *Grid, from, to *Cell) []*Cell {
+	cells := []*Cell{}
+	m.traceLine(from, to, func(prev, next *Cell, diagonal bool) bool {
+		cells = append(cells, next)
+		return true
+	})
+	return cells
+}
+
+func TestSimplifyPath(t *testing.T) {
+
+	m := NewGrid(5, 5, 16, 16)
+	path := &Path{Cells: []*Cell{m.Get(0, 0), m.Get(1, 0), m.Get(2, 0), m.Get(2, 1), m.Get(2, 2), m.Get(3, 3), m.Get(4, 4)}}
+	want := []*Cell{m.Get(0, 0), m.Get(2, 0), m.Get(2, 2), m.Get(4, 4)}
+
+	simple := m.SimplifyPath(path)
+	if len(simple.Cells) != len(want) {
+		t.Fatalf("simplified Path has %d Cells, not %d", len(simple.Cells), len(want))
+	}
+	for i, cell := range want {
+		if simple.Cells[i] != cell {
+			t.Fatalf("Cell %d of the simplified Path is %s, not %s", i, simple.Cells[i], cell)
+		}
+	}
+
+}
+
+func TestStringPullPathOpenGrid(t *testing.T) {
+
+	m := NewGrid(8, 8, 16, 16)
+	path := m.GetPathFromCells(m.Get(0, 0), m.Get(7, 5), nil)
+
+	if pulled := m.StringPullPath(path, nil); len(pulled.Cells) != 2 {
+		t.Fatalf("Path across an open Grid should be pulled into a single line, not %d Cells", len(pulled.Cells))
+	}
+
+}
+
+func TestStringPullPathOptions(t *testing.T) {
+
+	m, options := boatGrid()
+	start, dest := m.Get(0, 0), m.Get(8, 6)
+	path := m.GetPathFromCells(start, dest, options)
+
+	// A straight line from one corner to the other would cross the island, which boats can't.
+	pulled := m.StringPullPath(path, options)
+	if len(pulled.Cells) != 3 || pulled.Cells[0] != start || pulled.Cells[2] != dest {
+		t.Fatalf("boat's Path should be pulled around one corner of the island, not %v", pulled.Cells)
+	}
+	for i := 1; i < len(pulled.Cells); i++ {
+		for _, cell := range lineCells(m, pulled.Cells[i-1], pulled.Cells[i]) {
+			if cell.Rune != '~' {
+				t.Fatalf("boat's pulled Path crosses land at %s", cell)
+			}
+		}
+	}
+
+}
+
+func TestStringPullPathCosts(t *testing.T) {
+
+	// The swamp down the middle is only costly for tanks, through their CostFunc.
+	m := NewGridFromStringArrays([]string{
+		"   s   ",
+		"   s   ",
+		"   s   ",
+		"   s   ",
+		"       ",
+	}, 16, 16)
+	options := &PathOptions{
+		Movement: MoveDiagonal,
+		Agent:    "tank",
+		CostFunc: func(from, to *Cell, cost float64, agent interface{}) float64 {
+			if agent == "tank" && to.Rune == 's' {
+				return cost * 20
+			}
+			return cost
+		},
+	}
+
+	path := m.GetPathFromCells(m.Get(0, 0), m.Get(6, 0), options)
+	pulled := m.StringPullPath(path, options)
+
+	for i := 1; i < len(pulled.Cells); i++ {
+		for _, cell := range lineCells(m, pulled.Cells[i-1], pulled.Cells[i]) {
+			if cell.Rune == 's' {
+				t.Fatalf("tank's pulled Path cuts through the swamp at %s", cell)
+			}
+		}
+	}
+
+	// A one-way step blocks a line from crossing it, too.
+	m = NewGrid(5, 3, 16, 16)
+	for y := 0; y < 2; y++ {
+		m.SetEdgePassable(m.Get(2, y), m.Get(3, y), false)
+	}
+	path = m.GetPathFromCells(m.Get(0, 0), m.Get(4, 0), nil)
+	pulled = m.StringPullPath(path, nil)
+
+	for i := 1; i < len(pulled.Cells); i++ {
+		prev := pulled.Cells[i-1]
+		for _, cell := range lineCells(m, pulled.Cells[i-1], pulled.Cells[i]) {
+			if prev.X == 2 && cell.X == 3 && prev.Y == cell.Y && cell.Y < 2 {
+				t.Fatalf("pulled Path steps from %s to %s, which can't be taken", prev, cell)
+			}
+			prev = cell
+		}
+	}
+
+}
+
+func TestCurvesStayPassable(t *testing.T) {
+
+	m, options := boatGrid()
+	path := m.StringPullPath(m.GetPathFromCells(m.Get(0, 0), m.Get(8, 6), options), options)
+
+	for _, curve := range [][]Vector{m.CatmullRomPath(path, 8, options), m.BezierPath(path, 8, options)} {
+
+		if first, last := curve[0], curve[len(curve)-1]; first != m.cellCenter(m.Get(0, 0)) || last != m.cellCenter(m.Get(8, 6)) {
+			t.Fatalf("curve runs from %v to %v, rather than from the start of the Path to its end", first, last)
+		}
+
+		// Any part of the curve within the Grid has to stay on the water.
+		for i := 1; i < len(curve); i++ {
+			m.CellsAlongSegment(curve[i-1].X, curve[i-1].Y, curve[i].X, curve[i].Y, func(cell *Cell) bool {
+				if cell.Rune != '~' {
+					t.Fatalf("curve from %v to %v crosses land at %s", curve[i-1], curve[i], cell)
+				}
+				return true
+			})
+		}
+
+	}
+
+}